@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -49,20 +50,35 @@ func main() {
 		log.Fatal("Failed to initialize database:", err)
 	}
 	defer db.Close()
+	db.SetDefaultLimit(cfg.DefaultLimit)
 
+	// log.Fatal would skip the deferred Close, so failures from here on
+	// close the database before exiting
 	botAPI, err := tgbotapi.NewBotAPI(cfg.BotToken)
 	if err != nil {
-		log.Fatal("Failed to create bot:", err)
+		log.Println("Failed to create bot:", err)
+		db.Close()
+		os.Exit(1)
 	}
 
 	printBanner(botAPI.Self.UserName)
 
 	b := bot.New(botAPI, db, cfg)
-	b.Start()
+	if err := b.Start(); err != nil {
+		log.Println("Failed to start bot:", err)
+		db.Close()
+		os.Exit(1)
+	}
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	log.Println("Shutting down bot...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := b.Shutdown(ctx); err != nil {
+		log.Printf("Shutdown did not complete cleanly: %v", err)
+	}
 }
@@ -1,44 +1,156 @@
 package bot
 
 import (
+	"context"
 	"log"
+	"net/http"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/levouinse/sofinco-bot/internal/config"
 	"github.com/levouinse/sofinco-bot/internal/database"
 	"github.com/levouinse/sofinco-bot/internal/handlers"
+	"github.com/levouinse/sofinco-bot/internal/limits"
+	"github.com/levouinse/sofinco-bot/internal/premium"
+	"github.com/levouinse/sofinco-bot/internal/scheduler"
+	"github.com/levouinse/sofinco-bot/internal/telegram"
 )
 
+// allowedUpdates lists the update types the bot asks Telegram for.
+// chat_member updates are only delivered when requested explicitly.
+var allowedUpdates = []string{"message", "edited_message", "callback_query", "my_chat_member", "chat_member"}
+
 type Bot struct {
-	api      *tgbotapi.BotAPI
-	db       *database.Database
-	config   *config.Config
-	handlers *handlers.Handler
+	// api receives updates and manages the webhook, sender is what
+	// handlers and plugins use to reply.
+	api        *tgbotapi.BotAPI
+	sender     telegram.Sender
+	db         *database.Database
+	config     *config.Config
+	handlers   *handlers.Handler
+	dispatcher *Dispatcher
+	scheduler  *scheduler.Scheduler
+	server     *http.Server
+	ctx        context.Context
+	cancel     context.CancelFunc
+
+	// schedulerDone is closed once the scheduler has returned.
+	schedulerDone chan struct{}
 }
 
 func New(api *tgbotapi.BotAPI, db *database.Database, cfg *config.Config) *Bot {
+	return NewWithSender(api, api, db, cfg)
+}
+
+// NewWithSender is like New but replies through sender instead of api.
+func NewWithSender(api *tgbotapi.BotAPI, sender telegram.Sender, db *database.Database, cfg *config.Config) *Bot {
+	ctx, cancel := context.WithCancel(context.Background())
 	b := &Bot{
 		api:    api,
+		sender: sender,
 		db:     db,
 		config: cfg,
+		ctx:    ctx,
+		cancel: cancel,
+
+		schedulerDone: make(chan struct{}),
 	}
-	b.handlers = handlers.New(api, db, cfg)
+	b.scheduler = scheduler.New(sender, db, cfg)
+	b.handlers = handlers.New(ctx, sender, db, cfg, b.scheduler)
+	b.handlers.SetUsername(api.Self.UserName)
+	b.dispatcher = NewDispatcher(cfg.Workers, cfg.QueueSize, b.handleUpdate)
 	return b
 }
 
-func (b *Bot) Start() {
+// Start begins receiving updates, either through a webhook when
+// WebhookURL is configured or through long polling otherwise. Background
+// jobs only start once the transport is up, so a failed Start leaves
+// nothing running.
+func (b *Bot) Start() error {
+	b.dispatcher.Start()
+
+	if err := b.handlers.SyncCommands(); err != nil {
+		log.Printf("Failed to publish command list: %v", err)
+	}
+	if err := b.handlers.RestoreGames(); err != nil {
+		log.Printf("Failed to restore games: %v", err)
+	}
+
+	if err := limits.NewResetter(b.db, b.config).Schedule(b.scheduler); err != nil {
+		return err
+	}
+	if err := premium.NewWatcher(b.db, b.sender, b.config).Schedule(b.scheduler); err != nil {
+		return err
+	}
+
+	if b.config.WebhookURL != "" {
+		if err := b.startWebhook(); err != nil {
+			return err
+		}
+	} else {
+		b.startPolling()
+	}
+
+	go func() {
+		b.scheduler.Run(b.ctx)
+		close(b.schedulerDone)
+	}()
+
+	return nil
+}
+
+func (b *Bot) startPolling() {
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
+	u.AllowedUpdates = allowedUpdates
 
 	updates := b.api.GetUpdatesChan(u)
 
 	go func() {
 		for update := range updates {
-			go b.handleUpdate(update)
+			b.dispatcher.Dispatch(update)
 		}
 	}()
 }
 
+// Shutdown stops receiving updates, cancels the context seen by plugins and
+// waits for the workers and the running job to drain. Plugins are then
+// given a chance to clean up and pending database writes are flushed. If
+// ctx expires before the workers are done, Shutdown returns ctx.Err()
+// without touching plugin state.
+func (b *Bot) Shutdown(ctx context.Context) error {
+	if b.server != nil {
+		b.stopWebhook(ctx)
+	} else {
+		b.api.StopReceivingUpdates()
+	}
+
+	b.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		b.dispatcher.Stop()
+		<-b.schedulerDone
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Printf("Shutdown deadline reached with %d updates pending", b.dispatcher.QueueDepth())
+		return ctx.Err()
+	}
+
+	b.handlers.Shutdown()
+
+	return b.db.Sync()
+}
+
+// QueueDepth returns the number of updates waiting for or being handled by
+// a worker.
+func (b *Bot) QueueDepth() int {
+	return b.dispatcher.QueueDepth()
+}
+
 func (b *Bot) handleUpdate(update tgbotapi.Update) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -50,5 +162,11 @@ func (b *Bot) handleUpdate(update tgbotapi.Update) {
 		b.handlers.HandleMessage(update.Message)
 	} else if update.CallbackQuery != nil {
 		b.handlers.HandleCallback(update.CallbackQuery)
+	} else if update.ChatMember != nil {
+		b.handlers.HandleChatMember(update.ChatMember)
+	} else if update.MyChatMember != nil {
+		b.handlers.HandleMyChatMember(update.MyChatMember)
+	} else if update.EditedMessage != nil {
+		b.handlers.HandleEditedMessage(update.EditedMessage)
 	}
 }
@@ -0,0 +1,556 @@
+package bot_test
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/levouinse/sofinco-bot/internal/bot"
+	"github.com/levouinse/sofinco-bot/internal/config"
+	"github.com/levouinse/sofinco-bot/internal/database"
+	"github.com/levouinse/sofinco-bot/internal/plugins"
+	"github.com/levouinse/sofinco-bot/internal/telegram/telegramtest"
+)
+
+const waitTimeout = 5 * time.Second
+
+func startBot(t *testing.T) *telegramtest.Server {
+	t.Helper()
+	return startBotWithDB(t, openDB(t))
+}
+
+func openDB(t *testing.T) *database.Database {
+	t.Helper()
+
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func startBotWithDB(t *testing.T, db *database.Database) *telegramtest.Server {
+	t.Helper()
+	return startBotWithConfig(t, db, testConfig())
+}
+
+func testConfig() *config.Config {
+	return &config.Config{
+		BotToken:        "TEST:token",
+		OwnerID:         1,
+		OwnerIDs:        []int64{1},
+		Workers:         4,
+		QueueSize:       10,
+		ShutdownTimeout: time.Second,
+		Location:        time.UTC,
+		DefaultLimit:    30,
+		PremiumLimit:    1000,
+	}
+}
+
+func startBotWithConfig(t *testing.T, db *database.Database, cfg *config.Config) *telegramtest.Server {
+	t.Helper()
+
+	srv := telegramtest.NewServer()
+	t.Cleanup(srv.Close)
+
+	api, err := srv.NewBotAPI()
+	if err != nil {
+		t.Fatalf("connect to fake api: %v", err)
+	}
+
+	b := bot.New(api, db, cfg)
+	if err := b.Start(); err != nil {
+		t.Fatalf("start bot: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		b.Shutdown(ctx)
+	})
+
+	return srv
+}
+
+func textContains(substr string) func(telegramtest.Call) bool {
+	return func(c telegramtest.Call) bool {
+		return strings.Contains(c.Params.Get("text"), substr)
+	}
+}
+
+func callbackData(t *testing.T, prefix string, args ...string) string {
+	t.Helper()
+	data, err := plugins.CallbackData(prefix, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestStartShowsMenu(t *testing.T) {
+	srv := startBot(t)
+
+	srv.PushMessage(100, 100, "/start")
+
+	call, err := srv.WaitFor("sendMessage", 0, textContains("Sofinco Bot"), waitTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if call.Params.Get("chat_id") != "100" {
+		t.Errorf("chat_id = %q, want 100", call.Params.Get("chat_id"))
+	}
+	if !strings.Contains(call.Params.Get("reply_markup"), "menu|cat|game") {
+		t.Errorf("reply_markup missing category buttons: %s", call.Params.Get("reply_markup"))
+	}
+}
+
+func TestCallbackMenuNavigation(t *testing.T) {
+	srv := startBot(t)
+
+	srv.PushMessage(100, 100, "/start")
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("Sofinco Bot"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.PushCallback(100, 100, 1, callbackData(t, "menu", "cat", "game", "0"))
+	if _, err := srv.WaitFor("editMessageText", 0, textContains("Game Commands"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.WaitFor("answerCallbackQuery", 0, nil, waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.PushCallback(100, 100, 1, callbackData(t, "menu", "home"))
+	if _, err := srv.WaitFor("editMessageText", 1, textContains("Select a category"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestForgedCallbackRejected(t *testing.T) {
+	srv := startBot(t)
+
+	srv.PushCallback(100, 1, 1, "menu|cat|owner|0|AAAAAAAAAAA")
+	call, err := srv.WaitFor("answerCallbackQuery", 0, nil, waitTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if call.Params.Get("show_alert") != "true" {
+		t.Errorf("forged callback answered without alert: %v", call.Params)
+	}
+	if calls := srv.CallsTo("editMessageText"); len(calls) != 0 {
+		t.Errorf("forged callback edited the message: %v", calls)
+	}
+}
+
+func TestTicTacToeRound(t *testing.T) {
+	db := openDB(t)
+	srv := startBotWithDB(t, db)
+
+	const chat, playerX, playerO, bystander = -500, 201, 202, 203
+
+	srv.PushMessage(chat, playerX, "/tictactoe")
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("Menunggu pemain lain"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.PushMessage(chat, playerO, "/tictactoe")
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("Giliran"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	moves := []struct {
+		player int64
+		cell   string
+	}{
+		{bystander, "9"},
+		{playerX, "1"}, {playerO, "4"},
+		{playerX, "2"}, {playerO, "5"},
+		{playerX, "3"},
+	}
+	for _, m := range moves {
+		srv.PushMessage(chat, m.player, m.cell)
+	}
+
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("menang"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range srv.CallsTo("sendMessage") {
+		if strings.Contains(c.Params.Get("text"), "Bukan giliran") {
+			t.Errorf("bystander's message was treated as a move: %q", c.Params.Get("text"))
+		}
+	}
+
+	winner, err := db.GetUser(playerX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if winner.Exp != 100 || winner.Money != 1000 {
+		t.Errorf("winner has %d XP and %d money, want 100 and 1000", winner.Exp, winner.Money)
+	}
+	if winner.Wins != 1 {
+		t.Errorf("winner has %d wins, want 1", winner.Wins)
+	}
+	loser, err := db.GetUser(playerO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loser.Exp != 0 || loser.Money != 0 || loser.Wins != 0 {
+		t.Errorf("loser was rewarded: %d XP, %d money, %d wins", loser.Exp, loser.Money, loser.Wins)
+	}
+}
+
+func TestWebhookListenFailure(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+
+	srv := telegramtest.NewServer()
+	t.Cleanup(srv.Close)
+	api, err := srv.NewBotAPI()
+	if err != nil {
+		t.Fatalf("connect to fake api: %v", err)
+	}
+	cfg := &config.Config{
+		BotToken:        "TEST:token",
+		Workers:         1,
+		QueueSize:       1,
+		ShutdownTimeout: time.Second,
+		Location:        time.UTC,
+		WebhookURL:      "https://example.com/hook",
+		WebhookListen:   taken.Addr().String(),
+	}
+	b := bot.New(api, openDB(t), cfg)
+	if err := b.Start(); err == nil {
+		t.Fatal("Start succeeded with the listen address in use")
+	}
+	if calls := srv.CallsTo("setWebhook"); len(calls) != 0 {
+		t.Errorf("setWebhook called %d times", len(calls))
+	}
+}
+
+func TestSlashOnlyPrefix(t *testing.T) {
+	cfg := testConfig()
+	cfg.Prefixes = []string{"/", "."}
+	srv := startBotWithConfig(t, openDB(t), cfg)
+	const group, admin = -900, 901
+	srv.Admins[group] = []int64{admin}
+
+	srv.PushMessage(group, admin, "/setprefix /")
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("Prefix grup diubah ke: /"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	// Updates of one chat are handled in order, so the dot command has
+	// been seen by the time the slash one is answered
+	srv.PushMessage(group, admin, ".limit")
+	srv.PushMessage(group, admin, "/limit")
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("Limit Information"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+	var replies int
+	for _, c := range srv.CallsTo("sendMessage") {
+		if strings.Contains(c.Params.Get("text"), "Limit Information") {
+			replies++
+		}
+	}
+	if replies != 1 {
+		t.Errorf("got %d limit replies, want only the one to /limit", replies)
+	}
+}
+
+func TestHelpHidesOwnerCommands(t *testing.T) {
+	srv := startBot(t)
+
+	srv.PushMessage(100, 100, "/help qrcode")
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("Generate QR code"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.PushMessage(100, 100, "/help broadcast")
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("/broadcast not found"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.PushMessage(1, 1, "/help broadcast")
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("Help: /broadcast"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStartPublishesScopedCommands(t *testing.T) {
+	srv := startBot(t)
+
+	byScope := make(map[string]string)
+	for _, call := range srv.CallsTo("setMyCommands") {
+		if call.Params.Get("language_code") == "" {
+			byScope[call.Params.Get("scope")] = call.Params.Get("commands")
+		}
+	}
+
+	everyone := byScope[`{"type":"default"}`]
+	if !strings.Contains(everyone, `"qrcode"`) || strings.Contains(everyone, `"broadcast"`) {
+		t.Errorf("default scope commands = %s", everyone)
+	}
+	for _, cmd := range []string{`"daily"`, `"weekly"`, `"monthly"`} {
+		if !strings.Contains(everyone, cmd) {
+			t.Errorf("default scope missing %s: %s", cmd, everyone)
+		}
+	}
+	if admins := byScope[`{"type":"all_chat_administrators"}`]; !strings.Contains(admins, `"setprefix"`) {
+		t.Errorf("admin scope missing setprefix: %s", admins)
+	}
+	if owner := byScope[`{"type":"chat","chat_id":1}`]; !strings.Contains(owner, `"broadcast"`) {
+		t.Errorf("owner scope missing broadcast: %s", owner)
+	}
+}
+
+func TestGameSessionsRestored(t *testing.T) {
+	db := openDB(t)
+	now := time.Now()
+	sessions := []*database.GameSession{
+		{
+			ChatID:    -600,
+			Game:      "family100",
+			State:     []byte(`{"Question":"Buah","Answers":["apel","jeruk"],"Answered":[true,false]}`),
+			StartedAt: now.Add(-time.Hour),
+			Deadline:  now.Add(-time.Minute),
+		},
+		{
+			ChatID:    -601,
+			Game:      "tebakkata",
+			State:     []byte(`{"Question":"Hewan berkaki empat","Answer":"kucing"}`),
+			StartedAt: now,
+			Deadline:  now.Add(time.Minute),
+		},
+	}
+	for _, s := range sessions {
+		if err := db.SaveGameSession(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	srv := startBotWithDB(t, db)
+
+	call, err := srv.WaitFor("sendMessage", 0, textContains("Waktu habis"), waitTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if call.Params.Get("chat_id") != "-600" || !strings.Contains(call.Params.Get("text"), "jeruk") {
+		t.Errorf("expired session announced as %v", call.Params)
+	}
+
+	srv.PushMessage(-601, 300, "Kucing")
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("Benar"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := db.GetGameSessions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 0 {
+		t.Errorf("finished sessions still saved: %v", saved)
+	}
+}
+
+func TestLevelUpCatchesUp(t *testing.T) {
+	db := openDB(t)
+	if err := db.SaveUser(&database.User{ID: 300, FirstName: "Budi", Exp: 600, Limit: 30}); err != nil {
+		t.Fatal(err)
+	}
+	srv := startBotWithDB(t, db)
+
+	srv.PushMessage(300, 300, "/levelup")
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("Budi naik ke level 3"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("Butuh *100 XP* lagi untuk level 4"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := db.GetUser(300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Level != 3 {
+		t.Errorf("level = %d, want 3", user.Level)
+	}
+}
+
+func TestLeaderboardScopes(t *testing.T) {
+	db := openDB(t)
+	for _, u := range []*database.User{
+		{ID: 401, FirstName: "Ana", Exp: 500},
+		{ID: 402, FirstName: "Budi", Exp: 900},
+		{ID: 403, FirstName: "Cici", Exp: 2000},
+	} {
+		if err := db.SaveUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := startBotWithDB(t, db)
+
+	const group = -700
+	srv.PushMessage(group, 402, "halo")
+	srv.PushMessage(group, 401, "/leaderboard")
+	call, err := srv.WaitFor("sendMessage", 0, textContains("Leaderboard XP"), waitTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := call.Params.Get("text")
+	if !strings.Contains(text, "Test Group") || strings.Contains(text, "Cici") {
+		t.Errorf("group leaderboard = %q", text)
+	}
+	if strings.Index(text, "Budi") > strings.Index(text, "Ana") {
+		t.Errorf("group leaderboard not sorted by XP: %q", text)
+	}
+
+	srv.PushMessage(group, 401, "/leaderboard global")
+	call, err = srv.WaitFor("sendMessage", 1, textContains("Leaderboard XP"), waitTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := call.Params.Get("text"); !strings.Contains(text, "🥇 Cici") {
+		t.Errorf("global leaderboard = %q", text)
+	}
+}
+
+func TestTransferAndShop(t *testing.T) {
+	db := openDB(t)
+	for _, u := range []*database.User{
+		{ID: 501, FirstName: "Ana", Money: 10000, Registered: true},
+		{ID: 502, FirstName: "Budi"},
+	} {
+		if err := db.SaveUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := startBotWithDB(t, db)
+
+	srv.PushMessage(501, 501, "/transfer 502 3000")
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("Berhasil transfer 3000 Money ke Budi"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+	srv.PushMessage(502, 502, "/shop limit10")
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("Money kamu tidak cukup"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+	srv.PushMessage(501, 501, "/shop limit10")
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("Berhasil membeli 10 Limit"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	ana, _ := db.GetUser(501)
+	budi, _ := db.GetUser(502)
+	if ana.Money != 2000 || budi.Money != 3000 {
+		t.Errorf("Ana money %d, Budi money %d", ana.Money, budi.Money)
+	}
+	entries, err := db.GetLedger(501, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Kind != database.LedgerPurchase || entries[1].Amount != -3000 || entries[1].Counterparty != 502 {
+		t.Errorf("ledger of Ana = %+v", entries)
+	}
+}
+
+func TestClaimStreaks(t *testing.T) {
+	db := openDB(t)
+	now := time.Now()
+	if err := db.SaveUser(&database.User{ID: 600, FirstName: "Ana", Claims: map[string]database.Claim{
+		"daily":  {LastAt: now.Add(-24 * time.Hour), Streak: 3},
+		"weekly": {LastAt: now.AddDate(0, 0, -15), Streak: 5},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	srv := startBotWithDB(t, db)
+
+	srv.PushMessage(600, 600, "/daily")
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("Streak: 4 (bonus +30%)"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+	srv.PushMessage(600, 600, "/daily")
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("sudah klaim hadiah harian"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+	srv.PushMessage(600, 600, "/weekly")
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("Hadiah Mingguan"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := db.GetUser(600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Claims["daily"].Streak != 4 || user.Claims["weekly"].Streak != 1 {
+		t.Errorf("claims = %+v", user.Claims)
+	}
+	if user.Money != 1300+10000 {
+		t.Errorf("money = %d, want %d", user.Money, 1300+10000)
+	}
+}
+
+func TestRegistration(t *testing.T) {
+	db := openDB(t)
+	srv := startBotWithDB(t, db)
+
+	srv.PushMessage(700, 700, "/transfer 1 100")
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("harus daftar dulu"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+	srv.PushMessage(700, 700, "/register Al.200")
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("nama harus 3-30 karakter"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+	srv.PushMessage(700, 700, "/register Budi Santoso.17")
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("Pendaftaran berhasil"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := db.GetUser(700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.Registered || user.Name != "Budi Santoso" || user.Age != 17 || user.Serial == "" {
+		t.Fatalf("registered user = %+v", user)
+	}
+
+	srv.PushMessage(700, 700, "/profile")
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("Name: Budi Santoso"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+	srv.PushMessage(700, 700, "/unregister WRONG")
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("Serial salah"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+	srv.PushMessage(700, 700, "/unregister "+user.Serial)
+	if _, err := srv.WaitFor("sendMessage", 0, textContains("sudah dihapus"), waitTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if user, _ := db.GetUser(700); user.Registered || user.Name != "" {
+		t.Errorf("unregistered user = %+v", user)
+	}
+
+	// The serial must not be shown to a group
+	const group = -800
+	srv.PushMessage(group, 700, "/register Budi.17")
+	call, err := srv.WaitFor("sendMessage", 0, func(c telegramtest.Call) bool {
+		return c.Params.Get("chat_id") == "-800" && strings.Contains(c.Params.Get("text"), "Pendaftaran berhasil")
+	}, waitTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err = db.GetUser(700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := call.Params.Get("text"); strings.Contains(text, user.Serial) || !strings.Contains(text, "/profile") {
+		t.Errorf("group registration reply = %q", text)
+	}
+}
@@ -0,0 +1,139 @@
+package bot
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Dispatcher runs updates on a fixed pool of workers. Every chat is pinned
+// to a single worker so updates from the same chat are handled in the order
+// they arrived, while different chats are processed in parallel.
+type Dispatcher struct {
+	handle  func(tgbotapi.Update)
+	queues  []chan tgbotapi.Update
+	pending int64
+	mu      sync.Mutex
+	stopped bool
+	// done is closed by Stop to release senders blocked on a full queue,
+	// and sending counts those senders so the queues are only closed once
+	// they are gone.
+	done    chan struct{}
+	sending sync.WaitGroup
+	wg      sync.WaitGroup
+}
+
+func NewDispatcher(workers, queueSize int, handle func(tgbotapi.Update)) *Dispatcher {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 1 {
+		queueSize = 1
+	}
+
+	d := &Dispatcher{
+		handle: handle,
+		queues: make([]chan tgbotapi.Update, workers),
+		done:   make(chan struct{}),
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan tgbotapi.Update, queueSize)
+	}
+	return d
+}
+
+func (d *Dispatcher) Start() {
+	for _, queue := range d.queues {
+		d.wg.Add(1)
+		go d.work(queue)
+	}
+}
+
+func (d *Dispatcher) work(queue chan tgbotapi.Update) {
+	defer d.wg.Done()
+	for update := range queue {
+		d.handle(update)
+		atomic.AddInt64(&d.pending, -1)
+	}
+}
+
+// Dispatch queues an update on the worker that owns its chat. When that
+// worker's queue is full the call blocks until there is room, which slows
+// down whoever is feeding updates in: the polling loop, or the webhook
+// handler, which then answers Telegram late. It returns false if the
+// dispatcher has been stopped, including while the call was blocked.
+func (d *Dispatcher) Dispatch(update tgbotapi.Update) bool {
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		return false
+	}
+	d.sending.Add(1)
+	d.mu.Unlock()
+	defer d.sending.Done()
+
+	queue := d.queues[uint64(chatKey(update))%uint64(len(d.queues))]
+	atomic.AddInt64(&d.pending, 1)
+
+	select {
+	case queue <- update:
+		return true
+	default:
+	}
+
+	log.Printf("Dispatcher queue full (%d pending), applying backpressure", d.QueueDepth())
+	select {
+	case queue <- update:
+		return true
+	case <-d.done:
+		atomic.AddInt64(&d.pending, -1)
+		return false
+	}
+}
+
+// QueueDepth returns the number of updates that are queued or running.
+func (d *Dispatcher) QueueDepth() int {
+	return int(atomic.LoadInt64(&d.pending))
+}
+
+// Stop stops accepting updates and waits for the queued ones to finish.
+// Updates still waiting for room in a full queue are dropped.
+func (d *Dispatcher) Stop() {
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		return
+	}
+	d.stopped = true
+	close(d.done)
+	d.mu.Unlock()
+
+	d.sending.Wait()
+	for _, queue := range d.queues {
+		close(queue)
+	}
+	d.wg.Wait()
+}
+
+// chatKey picks the key used to order updates. Updates without a chat fall
+// back to the sending user.
+func chatKey(update tgbotapi.Update) int64 {
+	switch {
+	case update.Message != nil:
+		return update.Message.Chat.ID
+	case update.EditedMessage != nil:
+		return update.EditedMessage.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		return update.CallbackQuery.Message.Chat.ID
+	case update.MyChatMember != nil:
+		return update.MyChatMember.Chat.ID
+	case update.ChatMember != nil:
+		return update.ChatMember.Chat.ID
+	}
+	if from := update.SentFrom(); from != nil {
+		return from.ID
+	}
+	return 0
+}
@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func message(chatID int64, id int) tgbotapi.Update {
+	return tgbotapi.Update{Message: &tgbotapi.Message{MessageID: id, Chat: &tgbotapi.Chat{ID: chatID}}}
+}
+
+func TestDispatcherKeepsChatOrder(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[int64][]int)
+	d := NewDispatcher(4, 8, func(u tgbotapi.Update) {
+		mu.Lock()
+		seen[u.Message.Chat.ID] = append(seen[u.Message.Chat.ID], u.Message.MessageID)
+		mu.Unlock()
+	})
+	d.Start()
+
+	const chats, perChat = 6, 50
+	for i := 0; i < perChat; i++ {
+		for chat := int64(1); chat <= chats; chat++ {
+			if !d.Dispatch(message(chat, i)) {
+				t.Fatal("Dispatch refused an update before Stop")
+			}
+		}
+	}
+	d.Stop()
+
+	for chat := int64(1); chat <= chats; chat++ {
+		ids := seen[chat]
+		if len(ids) != perChat {
+			t.Fatalf("chat %d: handled %d updates, want %d", chat, len(ids), perChat)
+		}
+		for i, id := range ids {
+			if id != i {
+				t.Fatalf("chat %d: update %d handled at position %d", chat, id, i)
+			}
+		}
+	}
+	if depth := d.QueueDepth(); depth != 0 {
+		t.Errorf("QueueDepth after Stop = %d", depth)
+	}
+}
+
+// blockedDispatcher returns a dispatcher with one worker stuck handling an
+// update and a full queue behind it. Closing release lets the worker go.
+func blockedDispatcher(t *testing.T) (d *Dispatcher, release chan struct{}) {
+	t.Helper()
+	release = make(chan struct{})
+	started := make(chan struct{}, 1)
+	d = NewDispatcher(1, 1, func(tgbotapi.Update) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+	})
+	d.Start()
+
+	d.Dispatch(message(1, 1))
+	<-started
+	d.Dispatch(message(1, 2))
+	return d, release
+}
+
+func TestDispatcherBackpressure(t *testing.T) {
+	d, release := blockedDispatcher(t)
+
+	done := make(chan bool)
+	go func() { done <- d.Dispatch(message(1, 3)) }()
+	select {
+	case <-done:
+		t.Fatal("Dispatch returned while the queue was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if ok := <-done; !ok {
+		t.Error("Dispatch refused the update once there was room")
+	}
+	d.Stop()
+}
+
+func TestDispatcherStopReleasesBlockedSender(t *testing.T) {
+	d, release := blockedDispatcher(t)
+
+	done := make(chan bool)
+	go func() { done <- d.Dispatch(message(1, 3)) }()
+	time.Sleep(20 * time.Millisecond)
+
+	stopped := make(chan struct{})
+	go func() {
+		d.Stop()
+		close(stopped)
+	}()
+	select {
+	case ok := <-done:
+		if ok {
+			t.Error("blocked Dispatch accepted the update during Stop")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not release the blocked Dispatch")
+	}
+
+	close(release)
+	<-stopped
+	if d.Dispatch(message(1, 4)) {
+		t.Error("Dispatch accepted an update after Stop")
+	}
+}
@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+func (b *Bot) startWebhook() error {
+	link, err := url.Parse(b.config.WebhookURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %w", err)
+	}
+
+	path := link.Path
+	if path == "" {
+		path = "/"
+	}
+
+	// Bind before setting the webhook, so Telegram is never pointed at a
+	// server that failed to start
+	ln, err := net.Listen("tcp", b.config.WebhookListen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", b.config.WebhookListen, err)
+	}
+
+	// WebhookConfig in tgbotapi has no secret_token field, so the request
+	// is built by hand.
+	params := tgbotapi.Params{"url": link.String()}
+	params.AddNonEmpty("secret_token", b.config.WebhookSecret)
+	if err := params.AddInterface("allowed_updates", allowedUpdates); err != nil {
+		return err
+	}
+	if _, err := b.api.MakeRequest("setWebhook", params); err != nil {
+		ln.Close()
+		return fmt.Errorf("failed to set webhook: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, b.serveWebhook)
+
+	b.server = &http.Server{
+		Addr:              b.config.WebhookListen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := b.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("Webhook server error: %v", err)
+		}
+	}()
+
+	log.Printf("Webhook listening on %s%s", b.config.WebhookListen, path)
+	return nil
+}
+
+func (b *Bot) serveWebhook(w http.ResponseWriter, r *http.Request) {
+	if b.config.WebhookSecret != "" {
+		token := r.Header.Get(secretTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(b.config.WebhookSecret)) != 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+
+	update, err := b.api.HandleUpdate(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// Dispatch blocks while the chat's queue is full, holding Telegram's
+	// request open. A refused update gets a 503 so Telegram retries it.
+	if !b.dispatcher.Dispatch(*update) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (b *Bot) stopWebhook(ctx context.Context) {
+	if _, err := b.api.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		log.Printf("Failed to delete webhook: %v", err)
+	}
+
+	if err := b.server.Shutdown(ctx); err != nil {
+		log.Printf("Failed to stop webhook server: %v", err)
+	}
+}
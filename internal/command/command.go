@@ -0,0 +1,188 @@
+// Package command splits chat messages into a command name and its
+// arguments.
+package command
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Command is a parsed command message.
+type Command struct {
+	// Name is the lowercased command without prefix or @BotUsername.
+	Name string
+	// Prefix is the prefix the command was typed with, such as "/".
+	Prefix string
+	// Args holds the arguments split on whitespace, with quoted
+	// arguments kept together. Their case is preserved.
+	Args []string
+	// RawArgs is everything after the command name, untouched apart from
+	// surrounding whitespace.
+	RawArgs string
+}
+
+// Slash is the prefix Telegram itself uses for bot commands.
+const Slash = "/"
+
+// WithSlash returns prefixes with Slash first, adding it if it is missing.
+func WithSlash(prefixes []string) []string {
+	for _, prefix := range prefixes {
+		if prefix == Slash {
+			return prefixes
+		}
+	}
+	return append([]string{Slash}, prefixes...)
+}
+
+// Parser recognizes commands addressed to one bot.
+type Parser struct {
+	// Username is the bot's username. Commands such as /start@OtherBot
+	// that name a different bot are ignored.
+	Username string
+	Prefixes []string
+	// Known reports whether a command exists. Prefixes other than Slash
+	// also appear in ordinary sentences ("!!!", "...right"), so with them
+	// only known commands are recognized. Nil accepts every command.
+	Known func(name string) bool
+}
+
+// Parse returns the command in text, if there is one.
+func (p *Parser) Parse(text string) (*Command, bool) {
+	text = strings.TrimSpace(text)
+
+	prefix := ""
+	for _, candidate := range p.Prefixes {
+		if candidate != "" && strings.HasPrefix(text, candidate) && len(candidate) > len(prefix) {
+			prefix = candidate
+		}
+	}
+	if prefix == "" {
+		return nil, false
+	}
+	rest := text[len(prefix):]
+
+	end := strings.IndexFunc(rest, unicode.IsSpace)
+	if end < 0 {
+		end = len(rest)
+	}
+	name, raw := rest[:end], strings.TrimSpace(rest[end:])
+
+	if at := strings.IndexByte(name, '@'); at >= 0 {
+		if !strings.EqualFold(name[at+1:], p.Username) {
+			return nil, false
+		}
+		name = name[:at]
+	}
+
+	name = strings.ToLower(name)
+	if !validName(name) {
+		return nil, false
+	}
+	if prefix != Slash && p.Known != nil && !p.Known(name) {
+		return nil, false
+	}
+
+	return &Command{
+		Name:    name,
+		Prefix:  prefix,
+		Args:    Split(raw),
+		RawArgs: raw,
+	}, true
+}
+
+// validName reports whether name looks like a command, so that messages
+// like "/ hello" or "/// " are not taken for one.
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Split splits s on whitespace. Text inside double quotes, straight or
+// curly, stays one argument; a backslash escapes a quote inside it.
+func Split(s string) []string {
+	var args []string
+	for _, t := range tokenize(s) {
+		args = append(args, t.value)
+	}
+	return args
+}
+
+// After returns s with its first n arguments removed, leaving the rest
+// exactly as typed. It is useful for commands like "/translate en <text>".
+func After(s string, n int) string {
+	if n <= 0 {
+		return strings.TrimSpace(s)
+	}
+	tokens := tokenize(s)
+	if n > len(tokens) {
+		return ""
+	}
+	return strings.TrimSpace(s[tokens[n-1].end:])
+}
+
+type token struct {
+	value string
+	end   int
+}
+
+func tokenize(s string) []token {
+	var (
+		tokens  []token
+		current strings.Builder
+		inToken bool
+		quote   rune
+	)
+
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+
+		switch {
+		case quote != 0:
+			next, nextSize := utf8.DecodeRuneInString(s[i+size:])
+			if r == '\\' && isQuote(next) {
+				current.WriteRune(next)
+				size += nextSize
+			} else if closes(quote, r) {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case isQuote(r):
+			quote = r
+			inToken = true
+		case unicode.IsSpace(r):
+			if inToken {
+				tokens = append(tokens, token{value: current.String(), end: i})
+				current.Reset()
+				inToken = false
+			}
+		default:
+			current.WriteRune(r)
+			inToken = true
+		}
+		i += size
+	}
+	if inToken {
+		tokens = append(tokens, token{value: current.String(), end: len(s)})
+	}
+	return tokens
+}
+
+func isQuote(r rune) bool {
+	return r == '"' || r == '“' || r == '”'
+}
+
+func closes(open, r rune) bool {
+	if open == '"' {
+		return r == '"'
+	}
+	return r == '”' || r == '“'
+}
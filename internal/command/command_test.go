@@ -0,0 +1,118 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	p := &Parser{Username: "SofincoBot", Prefixes: []string{"/", ".", "!", "!!"}}
+
+	tests := []struct {
+		text string
+		ok   bool
+		want Command
+	}{
+		{"/start", true, Command{Name: "start", Prefix: "/"}},
+		{"  /menu  ", true, Command{Name: "menu", Prefix: "/"}},
+		{
+			"/Cmd@SofincoBot ARGS Here",
+			true,
+			Command{Name: "cmd", Prefix: "/", Args: []string{"ARGS", "Here"}, RawArgs: "ARGS Here"},
+		},
+		{"/help@sofincobot", true, Command{Name: "help", Prefix: "/"}},
+		{
+			".tr en  Hello   World",
+			true,
+			Command{Name: "tr", Prefix: ".", Args: []string{"en", "Hello", "World"}, RawArgs: "en  Hello   World"},
+		},
+		{"!!ping", true, Command{Name: "ping", Prefix: "!!"}},
+		{"/start@OtherBot", false, Command{}},
+		{"hello", false, Command{}},
+		{"/", false, Command{}},
+		{"/ hello", false, Command{}},
+		{"/// ", false, Command{}},
+		{"/he-llo", false, Command{}},
+	}
+
+	for _, tt := range tests {
+		got, ok := p.Parse(tt.text)
+		if ok != tt.ok {
+			t.Errorf("Parse(%q) ok = %v, want %v", tt.text, ok, tt.ok)
+			continue
+		}
+		if ok && !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.text, *got, tt.want)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"a b  c", []string{"a", "b", "c"}},
+		{`a "b c" d`, []string{"a", "b c", "d"}},
+		{"“hello world” Again", []string{"hello world", "Again"}},
+		{"”reversed“", []string{"reversed"}},
+		{`"say \"hi\""`, []string{`say "hi"`}},
+		{`back\slash`, []string{`back\slash`}},
+		{`""`, []string{""}},
+		{`pre"quoted part"post x`, []string{"prequoted partpost", "x"}},
+		{`"unterminated quote`, []string{"unterminated quote"}},
+		{`a "b c`, []string{"a", "b c"}},
+		{`"abc”`, []string{"abc”"}},
+	}
+
+	for _, tt := range tests {
+		if got := Split(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Split(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestAfter(t *testing.T) {
+	s := `en  "Hello  World" and  More `
+
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{-1, `en  "Hello  World" and  More`},
+		{0, `en  "Hello  World" and  More`},
+		{1, `"Hello  World" and  More`},
+		{2, `and  More`},
+		{4, ``},
+		{5, ``},
+	}
+
+	for _, tt := range tests {
+		if got := After(s, tt.n); got != tt.want {
+			t.Errorf("After(%q, %d) = %q, want %q", s, tt.n, got, tt.want)
+		}
+	}
+
+	if got := After(`x "unterminated rest`, 1); got != `"unterminated rest` {
+		t.Errorf("After with an unterminated quote = %q", got)
+	}
+}
+
+func TestWithSlash(t *testing.T) {
+	tests := []struct {
+		in, want []string
+	}{
+		{nil, []string{"/"}},
+		{[]string{"."}, []string{"/", "."}},
+		{[]string{"/"}, []string{"/"}},
+		{[]string{".", "/"}, []string{".", "/"}},
+	}
+
+	for _, tt := range tests {
+		if got := WithSlash(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("WithSlash(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
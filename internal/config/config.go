@@ -4,6 +4,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
@@ -14,6 +15,46 @@ type Config struct {
 	APIKey        string
 	AksesKey      string
 	BaseAPIURL    string
+
+	// Webhook mode is enabled when WebhookURL is set, otherwise the bot
+	// falls back to long polling.
+	WebhookURL    string
+	WebhookListen string
+	WebhookSecret string
+
+	// Workers is the number of update workers and QueueSize the number of
+	// updates each worker can hold before the dispatcher applies backpressure.
+	Workers   int
+	QueueSize int
+
+	// ShutdownTimeout bounds how long the bot waits for in-flight updates
+	// when it is asked to stop.
+	ShutdownTimeout time.Duration
+
+	// Location is the timezone that defines a "day" for daily resets.
+	Location *time.Location
+
+	// DefaultLimit is the daily limit of free users and PremiumLimit the
+	// daily limit of premium users.
+	DefaultLimit int
+	PremiumLimit int
+
+	// PremiumMultiplier scales the XP and money premium users earn from
+	// rewards.
+	PremiumMultiplier int
+
+	// LevelBaseXP and LevelStepXP shape the XP curve: going from level n-1
+	// to n takes LevelBaseXP + LevelStepXP*(n-1) XP.
+	LevelBaseXP int
+	LevelStepXP int
+
+	// PremiumReminderDays lists how many days before expiry premium users
+	// get a reminder.
+	PremiumReminderDays []int
+
+	// Prefixes are the characters commands may start with. "/" is always
+	// accepted; group admins can override the others per chat.
+	Prefixes []string
 }
 
 func Load() *Config {
@@ -28,6 +69,11 @@ func Load() *Config {
 			}
 		}
 	}
+
+	webhookListen := os.Getenv("WEBHOOK_LISTEN")
+	if webhookListen == "" {
+		webhookListen = ":8080"
+	}
 	
 	return &Config{
 		BotToken:      os.Getenv("BOT_TOKEN"),
@@ -37,7 +83,37 @@ func Load() *Config {
 		APIKey:        os.Getenv("API_KEY"),
 		AksesKey:      os.Getenv("AKSES_KEY"),
 		BaseAPIURL:    "https://api.betabotz.eu.org",
+		WebhookURL:    os.Getenv("WEBHOOK_URL"),
+		WebhookListen: webhookListen,
+		WebhookSecret: os.Getenv("WEBHOOK_SECRET"),
+		Workers:       parseInt(os.Getenv("WORKERS"), 16),
+		QueueSize:     parseInt(os.Getenv("QUEUE_SIZE"), 100),
+
+		ShutdownTimeout: time.Duration(parseInt(os.Getenv("SHUTDOWN_TIMEOUT"), 30)) * time.Second,
+
+		Location:     loadLocation(os.Getenv("TIMEZONE")),
+		DefaultLimit: parseInt(os.Getenv("DEFAULT_LIMIT"), 30),
+		PremiumLimit: parseInt(os.Getenv("PREMIUM_LIMIT"), 1000),
+
+		PremiumMultiplier:   parseInt(os.Getenv("PREMIUM_MULTIPLIER"), 2),
+		LevelBaseXP:         parseInt(os.Getenv("LEVEL_BASE_XP"), 100),
+		LevelStepXP:         parseInt(os.Getenv("LEVEL_STEP_XP"), 50),
+		PremiumReminderDays: parseInts(os.Getenv("PREMIUM_REMINDER_DAYS"), []int{3, 1}),
+
+		Prefixes: ParsePrefixes(os.Getenv("PREFIXES"), []string{"/", ".", "!", "#"}),
+	}
+}
+
+// loadLocation loads the named timezone, defaulting to WIB (Asia/Jakarta).
+// A fixed UTC+7 zone is used when the system has no timezone database.
+func loadLocation(name string) *time.Location {
+	if name == "" {
+		name = "Asia/Jakarta"
+	}
+	if loc, err := time.LoadLocation(name); err == nil {
+		return loc
 	}
+	return time.FixedZone("WIB", 7*60*60)
 }
 
 func parseInt64(s string) int64 {
@@ -49,3 +125,40 @@ func parseInt64(s string) int64 {
 	}
 	return result
 }
+
+func parseInt(s string, fallback int) int {
+	if v, err := strconv.Atoi(strings.TrimSpace(s)); err == nil && v > 0 {
+		return v
+	}
+	return fallback
+}
+
+func parseInts(s string, fallback []int) []int {
+	var result []int
+	for _, part := range strings.Split(s, ",") {
+		if v, err := strconv.Atoi(strings.TrimSpace(part)); err == nil && v > 0 {
+			result = append(result, v)
+		}
+	}
+	if len(result) == 0 {
+		return fallback
+	}
+	return result
+}
+
+// ParsePrefixes parses a comma separated list of command prefixes. The
+// slash prefix is always included so native Telegram commands keep working.
+func ParsePrefixes(s string, fallback []string) []string {
+	result := []string{"/"}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" || part == "/" {
+			continue
+		}
+		result = append(result, part)
+	}
+	if len(result) == 1 && strings.TrimSpace(s) == "" {
+		return fallback
+	}
+	return result
+}
@@ -2,16 +2,32 @@ package database
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"sync"
 	"time"
 
 	bolt "go.etcd.io/bbolt"
 )
 
+// ErrUserNotFound is returned when updating a user that was never saved.
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrInsufficientLimit is returned by ChargeLimit when the user does not
+// have enough limit left.
+var ErrInsufficientLimit = errors.New("insufficient limit")
+
 type Database struct {
-	db *bolt.DB
+	db           *bolt.DB
+	defaultLimit int
+
+	// prefixes caches the command prefixes of chats, which are needed for
+	// every message. SaveChat keeps it up to date.
+	prefixMu sync.RWMutex
+	prefixes map[int64][]string
 }
 
 type User struct {
@@ -20,12 +36,49 @@ type User struct {
 	FirstName    string    `json:"first_name"`
 	Limit        int       `json:"limit"`
 	Exp          int       `json:"exp"`
+	Money        int       `json:"money"`
+	Wins         int       `json:"wins"`
 	Level        int       `json:"level"`
 	Premium      bool      `json:"premium"`
 	PremiumUntil time.Time `json:"premium_until"`
 	Registered   bool      `json:"registered"`
 	RegisteredAt time.Time `json:"registered_at"`
 	LastSeen     time.Time `json:"last_seen"`
+
+	// BonusLimit is added to the daily quota the user is topped up to,
+	// e.g. for reaching a level milestone.
+	BonusLimit int `json:"bonus_limit,omitempty"`
+
+	// Name, Age and Serial are set when the user registers. Serial
+	// identifies the registration and confirms /unregister.
+	Name   string `json:"name,omitempty"`
+	Age    int    `json:"age,omitempty"`
+	Serial string `json:"serial,omitempty"`
+
+	// PremiumReminded holds the reminder offsets, in days before
+	// PremiumUntil, that were already sent for the current premium period.
+	PremiumReminded []int `json:"premium_reminded,omitempty"`
+
+	// Claims holds the last daily, weekly and monthly claims, by period.
+	Claims map[string]Claim `json:"claims,omitempty"`
+}
+
+// Claim is when a user last claimed a periodic reward and for how many
+// periods in a row they did.
+type Claim struct {
+	LastAt time.Time `json:"last_at"`
+	Streak int       `json:"streak"`
+}
+
+// ExpirePremium revokes premium once PremiumUntil has passed and reports
+// whether it did. A zero PremiumUntil means premium never expires.
+func (u *User) ExpirePremium(now time.Time) bool {
+	if !u.Premium || u.PremiumUntil.IsZero() || now.Before(u.PremiumUntil) {
+		return false
+	}
+	u.Premium = false
+	u.PremiumReminded = nil
+	return true
 }
 
 type Chat struct {
@@ -34,6 +87,32 @@ type Chat struct {
 	Title   string `json:"title"`
 	Welcome bool   `json:"welcome"`
 	Muted   bool   `json:"muted"`
+
+	// Prefixes overrides the configured command prefixes in this chat.
+	// "/" is always accepted, so []string{"/"} allows only slash commands.
+	Prefixes []string `json:"prefixes,omitempty"`
+}
+
+// Job is a unit of timed work kept by the scheduler. Jobs with a Spec
+// repeat on that cron schedule, the others run once at RunAt.
+type Job struct {
+	ID       string          `json:"id"`
+	Name     string          `json:"name"`
+	Payload  json.RawMessage `json:"payload,omitempty"`
+	Spec     string          `json:"spec,omitempty"`
+	RunAt    time.Time       `json:"run_at"`
+	LastRun  time.Time       `json:"last_run"`
+	Attempts int             `json:"attempts,omitempty"`
+}
+
+// GameSession is a game running in a chat, kept so that it survives a
+// restart of the bot. State is encoded by the game itself.
+type GameSession struct {
+	ChatID    int64           `json:"chat_id"`
+	Game      string          `json:"game"`
+	State     json.RawMessage `json:"state"`
+	StartedAt time.Time       `json:"started_at"`
+	Deadline  time.Time       `json:"deadline"`
 }
 
 func New(path string) (*Database, error) {
@@ -54,7 +133,33 @@ func New(path string) (*Database, error) {
 		if _, err := tx.CreateBucketIfNotExists([]byte("chats")); err != nil {
 			return err
 		}
-		return nil
+		if _, err := tx.CreateBucketIfNotExists([]byte("meta")); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte("premium_expiry")); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte("jobs")); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte("games")); err != nil {
+			return err
+		}
+		for _, name := range []string{"members", "memberships", "ranks", "ledger", "ledger_users", "usernames"} {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
+			}
+		}
+		if err := rebuildPremiumExpiry(tx); err != nil {
+			return err
+		}
+		if err := rebuildUsernames(tx); err != nil {
+			return err
+		}
+		if err := rebuildLedgerIndex(tx); err != nil {
+			return err
+		}
+		return rebuildRanks(tx)
 	})
 
 	if err != nil {
@@ -62,7 +167,17 @@ func New(path string) (*Database, error) {
 		return nil, err
 	}
 
-	return &Database{db: db}, nil
+	return &Database{db: db, defaultLimit: 30, prefixes: make(map[int64][]string)}, nil
+}
+
+// SetDefaultLimit sets the limit given to newly created users.
+func (d *Database) SetDefaultLimit(limit int) {
+	d.defaultLimit = limit
+}
+
+// Sync flushes pending writes to disk.
+func (d *Database) Sync() error {
+	return d.db.Sync()
 }
 
 func (d *Database) Close() error {
@@ -82,15 +197,95 @@ func (d *Database) GetUser(userID int64) (*User, error) {
 	return &user, err
 }
 
+func getUser(tx *bolt.Tx, userID int64) (*User, error) {
+	data := tx.Bucket([]byte("users")).Get(itob(userID))
+	if data == nil {
+		return nil, ErrUserNotFound
+	}
+	var user User
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// putUser saves user and keeps the premium expiry, username and
+// leaderboard indexes in line with it.
+func putUser(tx *bolt.Tx, user *User) error {
+	users := tx.Bucket([]byte("users"))
+
+	var old *User
+	if data := users.Get(itob(user.ID)); data != nil {
+		old = &User{}
+		if err := json.Unmarshal(data, old); err != nil {
+			old = nil
+		}
+	}
+
+	data, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+	if err := users.Put(itob(user.ID), data); err != nil {
+		return err
+	}
+	if err := putPremiumExpiry(tx, old, user); err != nil {
+		return err
+	}
+	if err := putUsername(tx, old, user); err != nil {
+		return err
+	}
+	return putRanks(tx, old, user)
+}
+
 func (d *Database) SaveUser(user *User) error {
 	return d.db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte("users"))
-		data, err := json.Marshal(user)
+		return putUser(tx, user)
+	})
+}
+
+// UpdateUser loads a user, applies fn and saves the result in a single
+// transaction. Nothing is written if fn returns an error.
+func (d *Database) UpdateUser(userID int64, fn func(user *User) error) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		user, err := getUser(tx, userID)
 		if err != nil {
 			return err
 		}
-		return b.Put(itob(user.ID), data)
+		if err := fn(user); err != nil {
+			return err
+		}
+		return putUser(tx, user)
+	})
+}
+
+// ChargeLimit takes cost from the user's limit and returns what is left.
+// It fails with ErrInsufficientLimit, leaving the limit untouched, when the
+// user has less than cost.
+func (d *Database) ChargeLimit(userID int64, cost int) (int, error) {
+	remaining := 0
+	err := d.UpdateUser(userID, func(user *User) error {
+		remaining = user.Limit
+		if user.Limit < cost {
+			return ErrInsufficientLimit
+		}
+		user.Limit -= cost
+		remaining = user.Limit
+		return nil
+	})
+	return remaining, err
+}
+
+// RefundLimit gives amount back to the user's limit and returns the new
+// limit.
+func (d *Database) RefundLimit(userID int64, amount int) (int, error) {
+	remaining := 0
+	err := d.UpdateUser(userID, func(user *User) error {
+		user.Limit += amount
+		remaining = user.Limit
+		return nil
 	})
+	return remaining, err
 }
 
 func (d *Database) GetOrCreateUser(userID int64, username, firstName string) (*User, error) {
@@ -104,7 +299,7 @@ func (d *Database) GetOrCreateUser(userID int64, username, firstName string) (*U
 			ID:           userID,
 			Username:     username,
 			FirstName:    firstName,
-			Limit:        30,
+			Limit:        d.defaultLimit,
 			Premium:      false,
 			Registered:   false,
 			RegisteredAt: time.Now(),
@@ -114,8 +309,18 @@ func (d *Database) GetOrCreateUser(userID int64, username, firstName string) (*U
 			return nil, err
 		}
 	} else {
-		user.LastSeen = time.Now()
-		d.SaveUser(user)
+		// Update in place so that changes made since GetUser, such as a
+		// reward from another chat, are not overwritten. The username is
+		// refreshed so that users can be found by it.
+		d.UpdateUser(userID, func(u *User) error {
+			u.LastSeen = time.Now()
+			u.ExpirePremium(u.LastSeen)
+			if username != "" {
+				u.Username = username
+			}
+			user = u
+			return nil
+		})
 	}
 
 	return user, nil
@@ -135,7 +340,7 @@ func (d *Database) GetChat(chatID int64) (*Chat, error) {
 }
 
 func (d *Database) SaveChat(chat *Chat) error {
-	return d.db.Update(func(tx *bolt.Tx) error {
+	err := d.db.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte("chats"))
 		data, err := json.Marshal(chat)
 		if err != nil {
@@ -143,12 +348,45 @@ func (d *Database) SaveChat(chat *Chat) error {
 		}
 		return b.Put(itob(chat.ID), data)
 	})
+	if err != nil {
+		return err
+	}
+
+	d.prefixMu.Lock()
+	d.prefixes[chat.ID] = chat.Prefixes
+	d.prefixMu.Unlock()
+	return nil
+}
+
+// ChatPrefixes returns the Prefixes of a chat, from memory after the first
+// read.
+func (d *Database) ChatPrefixes(chatID int64) ([]string, error) {
+	d.prefixMu.RLock()
+	prefixes, ok := d.prefixes[chatID]
+	d.prefixMu.RUnlock()
+	if ok {
+		return prefixes, nil
+	}
+
+	chat, err := d.GetChat(chatID)
+	if err != nil {
+		return nil, err
+	}
+	d.prefixMu.Lock()
+	d.prefixes[chatID] = chat.Prefixes
+	d.prefixMu.Unlock()
+	return chat.Prefixes, nil
 }
 
 func itob(v int64) []byte {
 	return []byte(fmt.Sprintf("%d", v))
 }
 
+func btoi(b []byte) int64 {
+	v, _ := strconv.ParseInt(string(b), 10, 64)
+	return v
+}
+
 func (d *Database) GetAllUsers() []*User {
 	var users []*User
 	d.db.View(func(tx *bolt.Tx) error {
@@ -176,3 +414,153 @@ func (d *Database) GetTotalUsers() int {
 	})
 	return count
 }
+
+// LastLimitReset returns when the daily limits were last reset.
+func (d *Database) LastLimitReset() (time.Time, error) {
+	var last time.Time
+	err := d.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket([]byte("meta")).Get([]byte("limit_reset"))
+		if data == nil {
+			return nil
+		}
+		return last.UnmarshalText(data)
+	})
+	return last, err
+}
+
+// ResetLimits tops every user up to their daily quota, freeQuota for free
+// users and premiumQuota for premium ones, plus their BonusLimit, and
+// records day as the last reset. Premium that lapsed before day is revoked
+// first, so those users get the free quota. Users above their quota keep
+// what they have. It does nothing and returns false if a reset for day or a
+// later day was already recorded, so calling it more than once for the same
+// day is safe.
+func (d *Database) ResetLimits(day time.Time, freeQuota, premiumQuota int) (bool, error) {
+	reset := false
+	err := d.db.Update(func(tx *bolt.Tx) error {
+		meta := tx.Bucket([]byte("meta"))
+
+		var last time.Time
+		if data := meta.Get([]byte("limit_reset")); data != nil {
+			if err := last.UnmarshalText(data); err != nil {
+				return err
+			}
+		}
+		if !last.Before(day) {
+			return nil
+		}
+
+		// The bucket must not be modified while iterating, so collect the
+		// users that changed first.
+		var changed []*User
+		tx.Bucket([]byte("users")).ForEach(func(k, v []byte) error {
+			var user User
+			if err := json.Unmarshal(v, &user); err != nil {
+				return nil
+			}
+
+			expired := user.ExpirePremium(day)
+			quota := freeQuota
+			if user.Premium {
+				quota = premiumQuota
+			}
+			quota += user.BonusLimit
+			if user.Limit < quota {
+				user.Limit = quota
+			} else if !expired {
+				return nil
+			}
+			changed = append(changed, &user)
+			return nil
+		})
+
+		for _, user := range changed {
+			if err := putUser(tx, user); err != nil {
+				return err
+			}
+		}
+
+		data, err := day.MarshalText()
+		if err != nil {
+			return err
+		}
+		reset = true
+		return meta.Put([]byte("limit_reset"), data)
+	})
+	return reset, err
+}
+
+func (d *Database) GetJob(id string) (*Job, error) {
+	var job *Job
+	err := d.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket([]byte("jobs")).Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+		job = &Job{}
+		return json.Unmarshal(data, job)
+	})
+	return job, err
+}
+
+func (d *Database) SaveJob(job *Job) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		data, err := json.Marshal(job)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket([]byte("jobs")).Put([]byte(job.ID), data)
+	})
+}
+
+func (d *Database) DeleteJob(id string) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte("jobs")).Delete([]byte(id))
+	})
+}
+
+func (d *Database) GetJobs() ([]*Job, error) {
+	var jobs []*Job
+	err := d.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte("jobs")).ForEach(func(k, v []byte) error {
+			var job Job
+			if err := json.Unmarshal(v, &job); err != nil {
+				return nil
+			}
+			jobs = append(jobs, &job)
+			return nil
+		})
+	})
+	return jobs, err
+}
+
+func (d *Database) SaveGameSession(session *GameSession) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		data, err := json.Marshal(session)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket([]byte("games")).Put(itob(session.ChatID), data)
+	})
+}
+
+func (d *Database) DeleteGameSession(chatID int64) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte("games")).Delete(itob(chatID))
+	})
+}
+
+func (d *Database) GetGameSessions() ([]*GameSession, error) {
+	var sessions []*GameSession
+	err := d.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte("games")).ForEach(func(k, v []byte) error {
+			var session GameSession
+			if err := json.Unmarshal(v, &session); err != nil {
+				return nil
+			}
+			sessions = append(sessions, &session)
+			return nil
+		})
+	})
+	return sessions, err
+}
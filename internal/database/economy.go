@@ -0,0 +1,285 @@
+package database
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrInsufficientMoney is returned when a user cannot afford a transfer or
+// a purchase.
+var ErrInsufficientMoney = errors.New("insufficient money")
+
+// Kinds of ledger entries.
+const (
+	LedgerReward   = "reward"
+	LedgerTransfer = "transfer"
+	LedgerPurchase = "purchase"
+)
+
+// LedgerEntry records a change to a user's money. Entries are never
+// changed or removed, so the ledger can be used to audit balances.
+type LedgerEntry struct {
+	ID     uint64 `json:"id"`
+	UserID int64  `json:"user_id"`
+	Kind   string `json:"kind"`
+	// Amount is the change, negative when money was taken, and Balance
+	// the user's money afterwards.
+	Amount  int `json:"amount"`
+	Balance int `json:"balance"`
+	// Counterparty is the other user of a transfer.
+	Counterparty int64     `json:"counterparty,omitempty"`
+	Note         string    `json:"note,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// appendLedger adds entry to the ledger, assigning its ID.
+func appendLedger(tx *bolt.Tx, entry *LedgerEntry) error {
+	b := tx.Bucket([]byte("ledger"))
+	id, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+	entry.ID = id
+	entry.CreatedAt = time.Now()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	if err := b.Put(key, data); err != nil {
+		return err
+	}
+	return tx.Bucket([]byte("ledger_users")).Put(ledgerUserKey(entry.UserID, id), key)
+}
+
+// ledgerUserKey is the key of entry id in the "ledger_users" index, which
+// orders the entries of each user so they can be read without scanning
+// the whole ledger.
+func ledgerUserKey(userID int64, id uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, uint64(userID))
+	binary.BigEndian.PutUint64(key[8:], id)
+	return key
+}
+
+// rebuildLedgerIndex indexes every ledger entry by user. It runs once, for
+// databases created before the index existed.
+func rebuildLedgerIndex(tx *bolt.Tx) error {
+	meta := tx.Bucket([]byte("meta"))
+	if meta.Get([]byte("ledger_users")) != nil {
+		return nil
+	}
+
+	index := tx.Bucket([]byte("ledger_users"))
+	err := tx.Bucket([]byte("ledger")).ForEach(func(k, v []byte) error {
+		var entry LedgerEntry
+		if err := json.Unmarshal(v, &entry); err != nil {
+			return nil
+		}
+		return index.Put(ledgerUserKey(entry.UserID, binary.BigEndian.Uint64(k)), k)
+	})
+	if err != nil {
+		return err
+	}
+	return meta.Put([]byte("ledger_users"), []byte("1"))
+}
+
+// UpdateBalance is UpdateUser for changes that may involve money. Any
+// change fn makes to the user's money is recorded in the ledger as kind,
+// with note.
+func (d *Database) UpdateBalance(userID int64, kind, note string, fn func(user *User) error) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		user, err := getUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		before := user.Money
+		if err := fn(user); err != nil {
+			return err
+		}
+		if err := putUser(tx, user); err != nil {
+			return err
+		}
+
+		if user.Money == before {
+			return nil
+		}
+		return appendLedger(tx, &LedgerEntry{
+			UserID:  userID,
+			Kind:    kind,
+			Amount:  user.Money - before,
+			Balance: user.Money,
+			Note:    note,
+		})
+	})
+}
+
+// Spend takes price from the user's money and applies fn, what they
+// bought, in the same transaction. It fails with ErrInsufficientMoney,
+// changing nothing, when the user cannot afford it.
+func (d *Database) Spend(userID int64, price int, note string, fn func(user *User) error) error {
+	return d.UpdateBalance(userID, LedgerPurchase, note, func(user *User) error {
+		if user.Money < price {
+			return ErrInsufficientMoney
+		}
+		user.Money -= price
+		return fn(user)
+	})
+}
+
+// Transfer moves amount of money from one user to another in a single
+// transaction. It fails with ErrInsufficientMoney when the sender has less
+// than amount.
+func (d *Database) Transfer(fromID, toID int64, amount int, note string) error {
+	if amount <= 0 {
+		return errors.New("transfer amount must be positive")
+	}
+	if fromID == toID {
+		return errors.New("cannot transfer to the same user")
+	}
+
+	return d.db.Update(func(tx *bolt.Tx) error {
+		from, err := getUser(tx, fromID)
+		if err != nil {
+			return err
+		}
+		to, err := getUser(tx, toID)
+		if err != nil {
+			return err
+		}
+		if from.Money < amount {
+			return ErrInsufficientMoney
+		}
+
+		from.Money -= amount
+		to.Money += amount
+		if err := putUser(tx, from); err != nil {
+			return err
+		}
+		if err := putUser(tx, to); err != nil {
+			return err
+		}
+
+		if err := appendLedger(tx, &LedgerEntry{
+			UserID:       fromID,
+			Kind:         LedgerTransfer,
+			Amount:       -amount,
+			Balance:      from.Money,
+			Counterparty: toID,
+			Note:         note,
+		}); err != nil {
+			return err
+		}
+		return appendLedger(tx, &LedgerEntry{
+			UserID:       toID,
+			Kind:         LedgerTransfer,
+			Amount:       amount,
+			Balance:      to.Money,
+			Counterparty: fromID,
+			Note:         note,
+		})
+	})
+}
+
+// GetLedger returns up to limit of the latest ledger entries of userID,
+// newest first.
+func (d *Database) GetLedger(userID int64, limit int) ([]*LedgerEntry, error) {
+	var entries []*LedgerEntry
+	err := d.db.View(func(tx *bolt.Tx) error {
+		ledger := tx.Bucket([]byte("ledger"))
+		prefix := ledgerUserKey(userID, 0)[:8]
+
+		// Start after the user's last entry and walk back
+		c := tx.Bucket([]byte("ledger_users")).Cursor()
+		k, v := c.Seek(ledgerUserKey(userID+1, 0))
+		if k == nil {
+			k, v = c.Last()
+		} else {
+			k, v = c.Prev()
+		}
+		for ; k != nil && bytes.HasPrefix(k, prefix) && len(entries) < limit; k, v = c.Prev() {
+			var entry LedgerEntry
+			if err := json.Unmarshal(ledger.Get(v), &entry); err != nil {
+				continue
+			}
+			entries = append(entries, &entry)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// FindUserByUsername returns the user with the given Telegram username,
+// without the leading "@". It returns nil if nobody has it.
+func (d *Database) FindUserByUsername(username string) (*User, error) {
+	var user *User
+	err := d.db.View(func(tx *bolt.Tx) error {
+		id := tx.Bucket([]byte("usernames")).Get(usernameKey(username))
+		if id == nil {
+			return nil
+		}
+		u, err := getUser(tx, btoi(id))
+		if errors.Is(err, ErrUserNotFound) {
+			return nil
+		}
+		user = u
+		return err
+	})
+	return user, err
+}
+
+func usernameKey(username string) []byte {
+	return []byte(strings.ToLower(strings.TrimPrefix(username, "@")))
+}
+
+// putUsername keeps the username index in line when a user's username
+// changes from old to user.Username.
+func putUsername(tx *bolt.Tx, old, user *User) error {
+	if old != nil && strings.EqualFold(old.Username, user.Username) {
+		return nil
+	}
+	b := tx.Bucket([]byte("usernames"))
+	if old != nil && old.Username != "" {
+		if id := b.Get(usernameKey(old.Username)); id != nil && btoi(id) == user.ID {
+			if err := b.Delete(usernameKey(old.Username)); err != nil {
+				return err
+			}
+		}
+	}
+	if user.Username == "" {
+		return nil
+	}
+	return b.Put(usernameKey(user.Username), itob(user.ID))
+}
+
+// rebuildUsernames indexes the usernames of every user. It runs once, for
+// databases created before the index existed.
+func rebuildUsernames(tx *bolt.Tx) error {
+	meta := tx.Bucket([]byte("meta"))
+	if meta.Get([]byte("usernames")) != nil {
+		return nil
+	}
+
+	var users []*User
+	tx.Bucket([]byte("users")).ForEach(func(k, v []byte) error {
+		var user User
+		if err := json.Unmarshal(v, &user); err == nil {
+			users = append(users, &user)
+		}
+		return nil
+	})
+	for _, user := range users {
+		if err := putUsername(tx, nil, user); err != nil {
+			return err
+		}
+	}
+	return meta.Put([]byte("usernames"), []byte("1"))
+}
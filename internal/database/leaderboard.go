@@ -0,0 +1,371 @@
+package database
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Board names a leaderboard.
+type Board string
+
+const (
+	BoardExp   Board = "xp"
+	BoardLevel Board = "level"
+	BoardMoney Board = "money"
+	BoardWins  Board = "wins"
+)
+
+// Boards lists every leaderboard in display order.
+var Boards = []Board{BoardExp, BoardLevel, BoardMoney, BoardWins}
+
+// Score returns the user's global score on board.
+func (u *User) Score(board Board) int {
+	switch board {
+	case BoardExp:
+		return u.Exp
+	case BoardLevel:
+		return u.Level
+	case BoardMoney:
+		return u.Money
+	case BoardWins:
+		return u.Wins
+	}
+	return 0
+}
+
+// Member is a user who has been seen in a group, with the games they won
+// there.
+type Member struct {
+	ChatID   int64     `json:"chat_id"`
+	UserID   int64     `json:"user_id"`
+	Wins     int       `json:"wins"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// score returns the member's score on board in their group. Wins are
+// counted per group, the other boards rank members by their global score.
+func (m *Member) score(user *User, board Board) int {
+	if board == BoardWins {
+		return m.Wins
+	}
+	return user.Score(board)
+}
+
+// Rank is a user's position on a leaderboard.
+type Rank struct {
+	Position int
+	User     *User
+	Score    int
+}
+
+// Leaderboards are kept as index buckets inside the "ranks" bucket, one per
+// board globally and one per board and group. Their keys sort by score
+// from highest to lowest, so a page of the ranking is read with a cursor
+// without decoding any user that is not shown:
+//
+//	ranks/xp         <score><user id>
+//	ranks/-100123:xp <score><user id>
+//
+// Group membership is kept twice, "members/<chat id>/<user id>" holds the
+// Member and "memberships/<user id>/<chat id>" lets a change to a user be
+// applied to the boards of every group they are in.
+
+func boardName(chatID int64, board Board) []byte {
+	if chatID == 0 {
+		return []byte(board)
+	}
+	return []byte(fmt.Sprintf("%d:%s", chatID, board))
+}
+
+// rankKey orders higher scores first and ties by user ID.
+func rankKey(score int, userID int64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, ^(uint64(score) ^ 1<<63))
+	binary.BigEndian.PutUint64(key[8:], uint64(userID))
+	return key
+}
+
+func rankUserID(key []byte) int64 {
+	return int64(binary.BigEndian.Uint64(key[8:]))
+}
+
+func rankScore(key []byte) int {
+	return int(int64(^binary.BigEndian.Uint64(key) ^ 1<<63))
+}
+
+// moveRank replaces the user's entry on a board. A nil old adds the user,
+// a nil score removes them.
+func moveRank(tx *bolt.Tx, chatID int64, board Board, userID int64, old, score *int) error {
+	b, err := tx.Bucket([]byte("ranks")).CreateBucketIfNotExists(boardName(chatID, board))
+	if err != nil {
+		return err
+	}
+	if old != nil {
+		if err := b.Delete(rankKey(*old, userID)); err != nil {
+			return err
+		}
+	}
+	if score != nil {
+		return b.Put(rankKey(*score, userID), nil)
+	}
+	return nil
+}
+
+// putRanks moves the user's entries on the leaderboards when their scores
+// change. A nil old adds the user to every board.
+func putRanks(tx *bolt.Tx, old, user *User) error {
+	var chats [][]byte
+	if b := tx.Bucket([]byte("memberships")).Bucket(itob(user.ID)); b != nil {
+		b.ForEach(func(k, v []byte) error {
+			chats = append(chats, append([]byte(nil), k...))
+			return nil
+		})
+	}
+
+	for _, board := range Boards {
+		score := user.Score(board)
+		var oldScore *int
+		if old != nil {
+			s := old.Score(board)
+			if s == score {
+				continue
+			}
+			oldScore = &s
+		}
+
+		if err := moveRank(tx, 0, board, user.ID, oldScore, &score); err != nil {
+			return err
+		}
+		if board == BoardWins {
+			continue
+		}
+		for _, chat := range chats {
+			if err := moveRank(tx, btoi(chat), board, user.ID, oldScore, &score); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rebuildRanks indexes every user on the global boards. It runs once, for
+// databases created before leaderboards existed.
+func rebuildRanks(tx *bolt.Tx) error {
+	meta := tx.Bucket([]byte("meta"))
+	if meta.Get([]byte("ranks")) != nil {
+		return nil
+	}
+
+	var users []*User
+	tx.Bucket([]byte("users")).ForEach(func(k, v []byte) error {
+		var user User
+		if err := json.Unmarshal(v, &user); err == nil {
+			users = append(users, &user)
+		}
+		return nil
+	})
+	for _, user := range users {
+		for _, board := range Boards {
+			score := user.Score(board)
+			if err := moveRank(tx, 0, board, user.ID, nil, &score); err != nil {
+				return err
+			}
+		}
+	}
+	return meta.Put([]byte("ranks"), []byte("1"))
+}
+
+// AddMember records that userID is in the group chatID. It does nothing if
+// they already are, or if the user was never saved.
+func (d *Database) AddMember(chatID, userID int64) error {
+	known := false
+	d.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte("members")).Bucket(itob(chatID)); b != nil {
+			known = b.Get(itob(userID)) != nil
+		}
+		return nil
+	})
+	if known {
+		return nil
+	}
+
+	return d.db.Update(func(tx *bolt.Tx) error {
+		data := tx.Bucket([]byte("users")).Get(itob(userID))
+		if data == nil {
+			return nil
+		}
+		var user User
+		if err := json.Unmarshal(data, &user); err != nil {
+			return err
+		}
+
+		members, err := tx.Bucket([]byte("members")).CreateBucketIfNotExists(itob(chatID))
+		if err != nil {
+			return err
+		}
+		if members.Get(itob(userID)) != nil {
+			return nil
+		}
+		member := &Member{ChatID: chatID, UserID: userID, JoinedAt: time.Now()}
+		if data, err = json.Marshal(member); err != nil {
+			return err
+		}
+		if err := members.Put(itob(userID), data); err != nil {
+			return err
+		}
+
+		chats, err := tx.Bucket([]byte("memberships")).CreateBucketIfNotExists(itob(userID))
+		if err != nil {
+			return err
+		}
+		if err := chats.Put(itob(chatID), nil); err != nil {
+			return err
+		}
+
+		for _, board := range Boards {
+			score := member.score(&user, board)
+			if err := moveRank(tx, chatID, board, userID, nil, &score); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveMember forgets that userID is in the group chatID, along with the
+// games they won there.
+func (d *Database) RemoveMember(chatID, userID int64) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		members := tx.Bucket([]byte("members")).Bucket(itob(chatID))
+		if members == nil {
+			return nil
+		}
+		data := members.Get(itob(userID))
+		if data == nil {
+			return nil
+		}
+
+		var member Member
+		if err := json.Unmarshal(data, &member); err != nil {
+			return err
+		}
+		var user User
+		if data := tx.Bucket([]byte("users")).Get(itob(userID)); data != nil {
+			if err := json.Unmarshal(data, &user); err != nil {
+				return err
+			}
+		}
+
+		for _, board := range Boards {
+			score := member.score(&user, board)
+			if err := moveRank(tx, chatID, board, userID, &score, nil); err != nil {
+				return err
+			}
+		}
+		if chats := tx.Bucket([]byte("memberships")).Bucket(itob(userID)); chats != nil {
+			if err := chats.Delete(itob(chatID)); err != nil {
+				return err
+			}
+		}
+		return members.Delete(itob(userID))
+	})
+}
+
+// RecordWin counts a game won by userID in chatID, globally and, when
+// chatID is a group they are a member of, for that group.
+func (d *Database) RecordWin(chatID, userID int64) error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		user, err := getUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		user.Wins++
+		if err := putUser(tx, user); err != nil {
+			return err
+		}
+
+		members := tx.Bucket([]byte("members")).Bucket(itob(chatID))
+		if members == nil {
+			return nil
+		}
+		data := members.Get(itob(userID))
+		if data == nil {
+			return nil
+		}
+		var member Member
+		if err := json.Unmarshal(data, &member); err != nil {
+			return err
+		}
+		old := member.Wins
+		member.Wins++
+		if data, err := json.Marshal(&member); err != nil {
+			return err
+		} else if err := members.Put(itob(userID), data); err != nil {
+			return err
+		}
+		return moveRank(tx, chatID, BoardWins, userID, &old, &member.Wins)
+	})
+}
+
+// Leaderboard returns up to limit ranks of board starting at offset,
+// along with the number of ranked users. A chatID of 0 selects the global
+// board, any other the board of that group.
+func (d *Database) Leaderboard(chatID int64, board Board, offset, limit int) ([]Rank, int, error) {
+	var ranks []Rank
+	total := 0
+	err := d.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("ranks")).Bucket(boardName(chatID, board))
+		if b == nil {
+			return nil
+		}
+		users := tx.Bucket([]byte("users"))
+
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			total++
+			if total <= offset || len(ranks) >= limit {
+				continue
+			}
+
+			var user User
+			data := users.Get(itob(rankUserID(k)))
+			if data == nil {
+				continue
+			}
+			if err := json.Unmarshal(data, &user); err != nil {
+				continue
+			}
+			ranks = append(ranks, Rank{Position: total, User: &user, Score: rankScore(k)})
+		}
+		return nil
+	})
+	return ranks, total, err
+}
+
+// UserRank returns the position of userID on board, or 0 if they are not
+// ranked there.
+func (d *Database) UserRank(chatID int64, board Board, userID int64) (int, error) {
+	position := 0
+	err := d.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("ranks")).Bucket(boardName(chatID, board))
+		if b == nil {
+			return nil
+		}
+
+		n := 0
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			n++
+			if rankUserID(k) == userID {
+				position = n
+				return nil
+			}
+		}
+		return nil
+	})
+	return position, err
+}
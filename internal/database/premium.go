@@ -0,0 +1,94 @@
+package database
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// The "premium_expiry" bucket indexes users whose premium runs out, keyed
+// by <expiry unix time><user id> so the users expiring soonest come first.
+// Users without premium or with permanent premium are not in it.
+
+func premiumExpiryKey(user *User) []byte {
+	if user == nil || !user.Premium || user.PremiumUntil.IsZero() {
+		return nil
+	}
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, uint64(user.PremiumUntil.Unix()))
+	binary.BigEndian.PutUint64(key[8:], uint64(user.ID))
+	return key
+}
+
+// putPremiumExpiry moves the user's entry in the expiry index when their
+// premium changes from old to user.
+func putPremiumExpiry(tx *bolt.Tx, old, user *User) error {
+	oldKey, newKey := premiumExpiryKey(old), premiumExpiryKey(user)
+	if bytes.Equal(oldKey, newKey) {
+		return nil
+	}
+	b := tx.Bucket([]byte("premium_expiry"))
+	if oldKey != nil {
+		if err := b.Delete(oldKey); err != nil {
+			return err
+		}
+	}
+	if newKey == nil {
+		return nil
+	}
+	return b.Put(newKey, nil)
+}
+
+// rebuildPremiumExpiry indexes every premium user. It runs once, for
+// databases created before the index existed.
+func rebuildPremiumExpiry(tx *bolt.Tx) error {
+	meta := tx.Bucket([]byte("meta"))
+	if meta.Get([]byte("premium_expiry")) != nil {
+		return nil
+	}
+
+	var users []*User
+	tx.Bucket([]byte("users")).ForEach(func(k, v []byte) error {
+		var user User
+		if err := json.Unmarshal(v, &user); err == nil {
+			users = append(users, &user)
+		}
+		return nil
+	})
+	for _, user := range users {
+		if err := putPremiumExpiry(tx, nil, user); err != nil {
+			return err
+		}
+	}
+	return meta.Put([]byte("premium_expiry"), []byte("1"))
+}
+
+// PremiumExpiringBy returns the premium users whose premium ends at or
+// before t, soonest first. Only the expiry index is scanned, so the cost depends
+// on how many users expire, not on the total number of users.
+func (d *Database) PremiumExpiringBy(t time.Time) ([]*User, error) {
+	var users []*User
+	err := d.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket([]byte("premium_expiry")).Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			if int64(binary.BigEndian.Uint64(k)) > t.Unix() {
+				break
+			}
+			user, err := getUser(tx, int64(binary.BigEndian.Uint64(k[8:])))
+			if err == ErrUserNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if !user.PremiumUntil.After(t) {
+				users = append(users, user)
+			}
+		}
+		return nil
+	})
+	return users, err
+}
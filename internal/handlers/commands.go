@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/levouinse/sofinco-bot/internal/i18n"
+)
+
+// maxBotCommands is the most commands Telegram accepts per scope.
+const maxBotCommands = 100
+
+// validBotCommand matches the command names Telegram accepts in the
+// command list.
+var validBotCommand = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// audience is who a command is published for.
+type audience int
+
+const (
+	audienceEveryone audience = iota
+	audienceGroups
+	audienceGroupAdmins
+	audienceOwners
+)
+
+type commandScope struct {
+	scope    tgbotapi.BotCommandScope
+	audience audience
+}
+
+// SyncCommands publishes the command list shown in Telegram's "/" menu.
+// Telegram picks the most specific scope that matches a chat, so every
+// scope lists all the commands its audience may use:
+//
+//   - default: commands anyone can use anywhere
+//   - all_group_chats: plus group-only commands
+//   - all_chat_administrators: plus commands for group admins
+//   - chat scope of each owner: plus owner commands
+//
+// Each list is published once per supported language.
+func (h *Handler) SyncCommands() error {
+	scopes := []commandScope{
+		{tgbotapi.NewBotCommandScopeDefault(), audienceEveryone},
+		{tgbotapi.NewBotCommandScopeAllGroupChats(), audienceGroups},
+		{tgbotapi.NewBotCommandScopeAllChatAdministrators(), audienceGroupAdmins},
+	}
+	for _, ownerID := range h.config.OwnerIDs {
+		if ownerID != 0 {
+			scopes = append(scopes, commandScope{tgbotapi.NewBotCommandScopeChat(ownerID), audienceOwners})
+		}
+	}
+
+	for _, s := range scopes {
+		scope := s.scope
+		for _, lang := range i18n.Languages() {
+			config := tgbotapi.SetMyCommandsConfig{
+				Commands: h.botCommands(s.audience, lang),
+				Scope:    &scope,
+			}
+			// The default language doubles as the fallback for users whose
+			// language has no list of its own.
+			if lang != i18n.DefaultLanguage {
+				config.LanguageCode = lang
+			}
+			if _, err := h.api.Request(config); err != nil {
+				return fmt.Errorf("set commands for scope %s (%s): %w", scope.Type, lang, err)
+			}
+		}
+	}
+	return nil
+}
+
+// botCommands lists the commands for an audience, described in lang.
+func (h *Handler) botCommands(who audience, lang string) []tgbotapi.BotCommand {
+	var commands []tgbotapi.BotCommand
+	for _, entry := range h.allEntries() {
+		if !entry.visibleTo(who) {
+			continue
+		}
+
+		name := entry.Commands[0]
+		if !validBotCommand.MatchString(name) {
+			continue
+		}
+
+		description := i18n.CommandHelp(lang, name, entry.Help)
+		if description == "" {
+			description = name
+		}
+		if runes := []rune(description); len(runes) > 256 {
+			description = string(runes[:256])
+		}
+
+		commands = append(commands, tgbotapi.BotCommand{Command: name, Description: description})
+		if len(commands) == maxBotCommands {
+			break
+		}
+	}
+	return commands
+}
+
+// visibleTo reports whether the command belongs in the list for who.
+func (e menuEntry) visibleTo(who audience) bool {
+	if e.ownerOnly() {
+		return who == audienceOwners
+	}
+	if p := e.Plugin; p != nil {
+		if p.RequireAdmin() {
+			return who == audienceGroupAdmins
+		}
+		if p.RequireGroup() {
+			return who == audienceGroups || who == audienceGroupAdmins
+		}
+	}
+	return true
+}
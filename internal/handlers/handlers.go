@@ -1,182 +1,148 @@
 package handlers
 
 import (
+	"context"
+	"crypto/sha256"
 	"fmt"
 	"log"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/levouinse/sofinco-bot/internal/command"
 	"github.com/levouinse/sofinco-bot/internal/config"
 	"github.com/levouinse/sofinco-bot/internal/database"
-	"github.com/levouinse/sofinco-bot/internal/downloader"
 	"github.com/levouinse/sofinco-bot/internal/plugins"
 	"github.com/levouinse/sofinco-bot/internal/plugins/game"
+	"github.com/levouinse/sofinco-bot/internal/scheduler"
+	"github.com/levouinse/sofinco-bot/internal/telegram"
 	_ "github.com/levouinse/sofinco-bot/internal/plugins/ai"
 	_ "github.com/levouinse/sofinco-bot/internal/plugins/downloader"
 	_ "github.com/levouinse/sofinco-bot/internal/plugins/maker"
 	_ "github.com/levouinse/sofinco-bot/internal/plugins/owner"
+	_ "github.com/levouinse/sofinco-bot/internal/plugins/rpg"
 	_ "github.com/levouinse/sofinco-bot/internal/plugins/stalker"
 	_ "github.com/levouinse/sofinco-bot/internal/plugins/tools"
 )
 
 type Handler struct {
-	api        *tgbotapi.BotAPI
-	db         *database.Database
-	config     *config.Config
-	downloader *downloader.YouTubeDownloader
-	startTime  time.Time
+	ctx       context.Context
+	api       telegram.Sender
+	db        *database.Database
+	config    *config.Config
+	scheduler *scheduler.Scheduler
+	username  string
+	startTime time.Time
 }
 
-func New(api *tgbotapi.BotAPI, db *database.Database, cfg *config.Config) *Handler {
+func New(ctx context.Context, api telegram.Sender, db *database.Database, cfg *config.Config, sched *scheduler.Scheduler) *Handler {
+	// Buttons are signed with a key derived from the bot token, so they
+	// stay valid across restarts but cannot be forged by users.
+	secret := sha256.Sum256([]byte("callback:" + cfg.BotToken))
+	plugins.SetCallbackSecret(secret[:])
+
 	return &Handler{
-		api:        api,
-		db:         db,
-		config:     cfg,
-		downloader: downloader.NewYouTubeDownloader(cfg.APIKey, cfg.BaseAPIURL),
-		startTime:  time.Now(),
+		ctx:       ctx,
+		api:       api,
+		db:        db,
+		config:    cfg,
+		scheduler: sched,
+		startTime: time.Now(),
 	}
 }
 
-func (h *Handler) HandleMessage(msg *tgbotapi.Message) {
-	if msg.Text == "" {
-		return
-	}
-
-	user, err := h.db.GetOrCreateUser(msg.From.ID, msg.From.UserName, msg.From.FirstName)
-	if err != nil {
-		log.Printf("Error getting user: %v", err)
-		return
-	}
+// SetUsername tells the handler the bot's username, so commands addressed
+// to other bots in a group (/start@OtherBot) are ignored.
+func (h *Handler) SetUsername(username string) {
+	h.username = username
+}
 
-	// Check for game responses first (before command parsing)
-	text := strings.TrimSpace(msg.Text)
-	
-	// Check TicTacToe move
-	if game.TicTacToeInstance != nil {
-		if response, handled := game.TicTacToeInstance.HandleMove(msg.Chat.ID, msg.From.ID, text); handled {
-			reply := tgbotapi.NewMessage(msg.Chat.ID, response)
-			reply.ParseMode = "HTML"
-			h.api.Send(reply)
-			return
-		}
+// parserFor returns a command parser using the prefixes of a chat, or the
+// configured ones when the chat has not set its own.
+func (h *Handler) parserFor(chatID int64) *command.Parser {
+	prefixes := h.config.Prefixes
+	if len(prefixes) == 0 {
+		prefixes = []string{command.Slash}
 	}
-
-	// Check Family100 answer
-	if game.Family100Instance != nil {
-		if response, handled := game.Family100Instance.CheckAnswer(msg.Chat.ID, msg.From.ID, text); handled {
-			reply := tgbotapi.NewMessage(msg.Chat.ID, response)
-			reply.ParseMode = "Markdown"
-			h.api.Send(reply)
-			return
-		}
+	if custom, err := h.db.ChatPrefixes(chatID); err == nil && len(custom) > 0 {
+		prefixes = command.WithSlash(custom)
 	}
 
-	// Check Math answer
-	if game.MathInstance != nil {
-		if game.MathInstance.CheckAnswer(&plugins.Context{
-			API:     h.api,
-			DB:      h.db,
-			Config:  h.config,
-			Message: msg,
-			User:    user,
-		}, text) {
-			return
-		}
+	return &command.Parser{
+		Username: h.username,
+		Prefixes: prefixes,
+		Known: func(name string) bool {
+			_, ok := plugins.Registry[name]
+			return ok || isBuiltin(name)
+		},
 	}
+}
 
-	// Check Tebak Anime answer
-	if game.TebakAnimeInstance != nil {
-		if response, handled := game.TebakAnimeInstance.CheckAnswer(msg.Chat.ID, text); handled {
-			reply := tgbotapi.NewMessage(msg.Chat.ID, response)
-			reply.ParseMode = "Markdown"
-			h.api.Send(reply)
-			return
+func (h *Handler) HandleMessage(msg *tgbotapi.Message) {
+	if left := msg.LeftChatMember; left != nil {
+		if err := h.db.RemoveMember(msg.Chat.ID, left.ID); err != nil {
+			log.Printf("Error removing member: %v", err)
 		}
 	}
 
-	// Check Tebak Gambar answer
-	if game.TebakGambarInstance != nil {
-		if response, handled := game.TebakGambarInstance.CheckAnswer(msg.Chat.ID, text); handled {
-			reply := tgbotapi.NewMessage(msg.Chat.ID, response)
-			reply.ParseMode = "Markdown"
-			h.api.Send(reply)
-			return
-		}
+	// Commands can also be sent as the caption of a photo or video.
+	text := msg.Text
+	if text == "" {
+		text = msg.Caption
 	}
-
-	// Check Tebak Kata answer
-	if game.TebakKataInstance != nil {
-		if response, handled := game.TebakKataInstance.CheckAnswer(msg.Chat.ID, text); handled {
-			reply := tgbotapi.NewMessage(msg.Chat.ID, response)
-			reply.ParseMode = "Markdown"
-			h.api.Send(reply)
-			return
-		}
+	if text == "" || msg.From == nil {
+		return
 	}
 
-	// Check Asah Otak answer
-	if game.AsahOtakInstance != nil {
-		if response, handled := game.AsahOtakInstance.CheckAnswer(msg.Chat.ID, text); handled {
-			reply := tgbotapi.NewMessage(msg.Chat.ID, response)
-			reply.ParseMode = "Markdown"
-			h.api.Send(reply)
-			return
-		}
+	user, err := h.db.GetOrCreateUser(msg.From.ID, msg.From.UserName, msg.From.FirstName)
+	if err != nil {
+		log.Printf("Error getting user: %v", err)
+		return
 	}
-
-	// Check Siapakah Aku answer
-	if game.SiapakahAkuInstance != nil {
-		if response, handled := game.SiapakahAkuInstance.CheckAnswer(msg.Chat.ID, text); handled {
-			reply := tgbotapi.NewMessage(msg.Chat.ID, response)
-			reply.ParseMode = "Markdown"
-			h.api.Send(reply)
-			return
+	if msg.Chat.IsGroup() || msg.Chat.IsSuperGroup() {
+		if err := h.db.AddMember(msg.Chat.ID, user.ID); err != nil {
+			log.Printf("Error adding member: %v", err)
 		}
 	}
 
-	// Check Tebak Lagu answer
-	if game.TebakLaguInstance != nil {
-		if response, handled := game.TebakLaguInstance.CheckAnswer(msg.Chat.ID, text); handled {
-			reply := tgbotapi.NewMessage(msg.Chat.ID, response)
-			reply.ParseMode = "Markdown"
-			h.api.Send(reply)
-			return
-		}
+	// Let a game running in this chat look at the message first
+	text = strings.TrimSpace(text)
+	if game.Sessions.HandleMessage(&plugins.Context{
+		Ctx:       h.ctx,
+		API:       h.api,
+		DB:        h.db,
+		Config:    h.config,
+		Scheduler: h.scheduler,
+		Message:   msg,
+		User:      user,
+	}) {
+		return
 	}
 
-	command := strings.ToLower(text)
-	if strings.HasPrefix(command, "/") {
-		command = strings.TrimPrefix(command, "/")
-		parts := strings.Fields(command)
-		if len(parts) == 0 {
-			return
-		}
-		cmd := parts[0]
-		args := parts[1:]
+	parsed, ok := h.parserFor(msg.Chat.ID).Parse(text)
+	if ok {
+		cmd := parsed.Name
 
 		// Check plugin registry first
 		if plugin, exists := plugins.Registry[cmd]; exists {
 			ctx := &plugins.Context{
-				API:     h.api,
-				DB:      h.db,
-				Config:  h.config,
-				Message: msg,
-				User:    user,
-				Args:    args,
-				Command: cmd,
-			}
-
-			// Check requirements
-			if plugin.RequireLimit() && user.Limit <= 0 && !user.Premium {
-				h.sendMessage(msg.Chat.ID, "❌ Limit Anda habis! Upgrade ke premium untuk akses unlimited.")
-				return
+				Ctx:       h.ctx,
+				API:       h.api,
+				DB:        h.db,
+				Config:    h.config,
+				Scheduler: h.scheduler,
+				Message:   msg,
+				User:      user,
+				Args:      parsed.Args,
+				RawArgs:   parsed.RawArgs,
+				Command:   cmd,
+				Plugin:    plugin,
+				Media:     plugins.FindMedia(msg),
 			}
 
-			if err := plugin.Execute(ctx); err != nil {
-				h.sendMessage(msg.Chat.ID, fmt.Sprintf("❌ Error: %v", err))
-			}
+			plugins.Pipeline(plugin)(ctx)
 			return
 		}
 
@@ -184,12 +150,12 @@ func (h *Handler) HandleMessage(msg *tgbotapi.Message) {
 		switch cmd {
 		case "start", "menu":
 			h.handleStart(msg, user)
+		case "help":
+			h.handleHelp(msg, parsed.Args)
 		case "ping":
 			h.handlePing(msg)
 		case "getid":
 			h.handleGetID(msg)
-		case "play":
-			h.handlePlay(msg, user)
 		case "limit":
 			h.handleLimit(msg, user)
 		case "profile":
@@ -200,6 +166,105 @@ func (h *Handler) HandleMessage(msg *tgbotapi.Message) {
 	}
 }
 
+// HandleEditedMessage passes an edited message to the plugins that want
+// to see edits.
+func (h *Handler) HandleEditedMessage(msg *tgbotapi.Message) {
+	var hooks []plugins.Plugin
+	for _, plugin := range plugins.All() {
+		if _, ok := plugin.(plugins.EditedMessageHandler); ok {
+			hooks = append(hooks, plugin)
+		}
+	}
+	if len(hooks) == 0 || msg.From == nil {
+		return
+	}
+
+	user, err := h.db.GetOrCreateUser(msg.From.ID, msg.From.UserName, msg.From.FirstName)
+	if err != nil {
+		log.Printf("Error getting user: %v", err)
+		return
+	}
+
+	for _, plugin := range hooks {
+		err := plugin.(plugins.EditedMessageHandler).OnEditedMessage(&plugins.Context{
+			Ctx:       h.ctx,
+			API:       h.api,
+			DB:        h.db,
+			Config:    h.config,
+			Scheduler: h.scheduler,
+			Message:   msg,
+			User:      user,
+			Plugin:    plugin,
+			Media:     plugins.FindMedia(msg),
+		})
+		if err != nil {
+			log.Printf("Edited message hook of %T failed: %v", plugin, err)
+		}
+	}
+}
+
+// HandleChatMember drops cached group admins whenever membership or
+// permissions change in a chat, then runs the plugin hooks.
+func (h *Handler) HandleChatMember(update *tgbotapi.ChatMemberUpdated) {
+	plugins.Admins.Invalidate(update.Chat.ID)
+	if member := update.NewChatMember; member.User != nil && (member.HasLeft() || member.WasKicked()) {
+		if err := h.db.RemoveMember(update.Chat.ID, member.User.ID); err != nil {
+			log.Printf("Error removing member: %v", err)
+		}
+	}
+
+	ctx := h.chatMemberContext(update)
+	for _, plugin := range plugins.All() {
+		if hook, ok := plugin.(plugins.ChatMemberHandler); ok {
+			if err := hook.OnChatMember(ctx); err != nil {
+				log.Printf("Chat member hook of %T failed: %v", plugin, err)
+			}
+		}
+	}
+}
+
+// HandleMyChatMember handles changes to the bot's own membership, such as
+// being added to a group or promoted to admin.
+func (h *Handler) HandleMyChatMember(update *tgbotapi.ChatMemberUpdated) {
+	plugins.Admins.Invalidate(update.Chat.ID)
+
+	ctx := h.chatMemberContext(update)
+	for _, plugin := range plugins.All() {
+		if hook, ok := plugin.(plugins.MyChatMemberHandler); ok {
+			if err := hook.OnMyChatMember(ctx); err != nil {
+				log.Printf("My chat member hook of %T failed: %v", plugin, err)
+			}
+		}
+	}
+}
+
+func (h *Handler) chatMemberContext(update *tgbotapi.ChatMemberUpdated) *plugins.ChatMemberContext {
+	return &plugins.ChatMemberContext{
+		Ctx:    h.ctx,
+		API:    h.api,
+		DB:     h.db,
+		Config: h.config,
+		Update: update,
+	}
+}
+
+// RestoreGames resumes the games that were running when the bot stopped.
+func (h *Handler) RestoreGames() error {
+	return game.Sessions.Restore(h.api, h.db)
+}
+
+// Shutdown lets plugins that keep state, such as running games, clean up
+// before the bot exits.
+func (h *Handler) Shutdown() {
+	game.Sessions.Shutdown(h.api)
+
+	for _, plugin := range plugins.All() {
+		if s, ok := plugin.(plugins.Shutdowner); ok {
+			s.Shutdown(h.api)
+		}
+	}
+}
+
 func (h *Handler) handleStart(msg *tgbotapi.Message, user *database.User) {
 	text := fmt.Sprintf("Hi %s! 👋\n\n"+
 		"*Sofinco Bot* - Your Telegram Assistant\n\n"+
@@ -210,23 +275,7 @@ func (h *Handler) handleStart(msg *tgbotapi.Message, user *database.User) {
 		"Select a category below:",
 		user.FirstName, user.Limit, user.Exp, user.Premium)
 
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📥 Downloader", "cat_downloader"),
-			tgbotapi.NewInlineKeyboardButtonData("🎮 Games", "cat_games"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🛠 Tools", "cat_tools"),
-			tgbotapi.NewInlineKeyboardButtonData("🤖 AI", "cat_ai"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("ℹ️ Info", "cat_info"),
-			tgbotapi.NewInlineKeyboardButtonData("👑 Owner", "cat_owner"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📊 Statistics", "stats"),
-		),
-	)
+	keyboard := h.menuKeyboard(msg.From.ID)
 
 	msgConfig := tgbotapi.NewMessage(msg.Chat.ID, text)
 	msgConfig.ReplyMarkup = keyboard
@@ -259,197 +308,88 @@ func (h *Handler) handleGetID(msg *tgbotapi.Message) {
 	h.sendMessage(msg.Chat.ID, text)
 }
 
-func (h *Handler) handlePlay(msg *tgbotapi.Message, user *database.User) {
-	args := strings.TrimPrefix(msg.Text, "/play ")
-	if args == msg.Text || args == "" {
-		h.sendMessage(msg.Chat.ID, "Usage: /play <song name or URL>")
-		return
+// HandleCallback verifies the callback data and routes it to the built-in
+// menu or to the plugin that registered its prefix.
+func (h *Handler) HandleCallback(callback *tgbotapi.CallbackQuery) {
+	ctx := &plugins.CallbackContext{
+		Ctx:       h.ctx,
+		API:       h.api,
+		DB:        h.db,
+		Config:    h.config,
+		Scheduler: h.scheduler,
+		Query:     callback,
+		Message:   callback.Message,
 	}
 
-	if user.Limit <= 0 && !user.Premium {
-		h.sendMessage(msg.Chat.ID, "Your limit has been exhausted. Upgrade to premium for unlimited access.")
+	prefix, args, ok := plugins.ParseCallbackData(callback.Data)
+	if !ok {
+		ctx.Alert("⌛ Tombol ini sudah tidak berlaku, silakan buka menu lagi.")
 		return
 	}
+	ctx.Prefix = prefix
+	ctx.Args = args
 
-	waitMsg := h.sendMessage(msg.Chat.ID, "Searching and downloading...")
-
-	result, err := h.downloader.Download(args)
+	user, err := h.db.GetOrCreateUser(callback.From.ID, callback.From.UserName, callback.From.FirstName)
 	if err != nil {
-		edit := tgbotapi.NewEditMessageText(msg.Chat.ID, waitMsg.MessageID, fmt.Sprintf("Error: %v", err))
-		h.api.Send(edit)
+		log.Printf("Error getting user: %v", err)
+		ctx.Answer("")
 		return
 	}
-
-	h.api.Request(tgbotapi.NewDeleteMessage(msg.Chat.ID, waitMsg.MessageID))
-
-	caption := fmt.Sprintf("Title: %s\nDuration: %s\nViews: %s\nAuthor: %s",
-		result.Result.Title,
-		result.Result.Duration,
-		result.Result.Views,
-		result.Result.Author,
-	)
-
-	photo := tgbotapi.NewPhoto(msg.Chat.ID, tgbotapi.FileURL(result.Result.Thumbnail))
-	photo.Caption = caption
-	h.api.Send(photo)
-
-	audio := tgbotapi.NewAudio(msg.Chat.ID, tgbotapi.FileURL(result.Result.MP3))
-	audio.Title = result.Result.Title
-	audio.Performer = result.Result.Author
-	h.api.Send(audio)
-
-	if !user.Premium {
-		user.Limit--
-		h.db.SaveUser(user)
-		h.sendMessage(msg.Chat.ID, fmt.Sprintf("Limit used: 1\nRemaining: %d", user.Limit))
+	ctx.User = user
+
+	if prefix == menuCallback {
+		err = h.handleMenuCallback(ctx)
+	} else if fn, found := plugins.CallbackRoute(prefix); found {
+		err = fn(ctx)
+	} else {
+		ctx.Alert("⌛ Tombol ini sudah tidak berlaku, silakan buka menu lagi.")
+		return
 	}
-}
 
-func (h *Handler) HandleCallback(callback *tgbotapi.CallbackQuery) {
-	h.api.Request(tgbotapi.NewCallback(callback.ID, ""))
-
-	data := callback.Data
-
-	switch {
-	case data == "stats":
-		h.handleStats(callback)
-	case strings.HasPrefix(data, "cat_"):
-		category := strings.TrimPrefix(data, "cat_")
-		h.handleCategory(callback, category)
-	case data == "back_menu":
-		h.handleBackToMenu(callback)
+	if err != nil {
+		log.Printf("Callback %s failed: %v", prefix, err)
+		if !ctx.Answered() {
+			ctx.Alert(fmt.Sprintf("❌ Error: %v", err))
+		}
+		return
+	}
+	if !ctx.Answered() {
+		ctx.Answer("")
 	}
 }
 
-func (h *Handler) handleStats(callback *tgbotapi.CallbackQuery) {
-	text := "Statistics\n\nUsers: 0\nChats: 0\nCommands: 0"
-
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("Back", "back_menu"),
-		),
-	)
-
-	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, text)
-	edit.ReplyMarkup = &keyboard
-	h.api.Send(edit)
-}
-
-func (h *Handler) handleCategory(callback *tgbotapi.CallbackQuery, category string) {
-	var text string
-	var keyboard tgbotapi.InlineKeyboardMarkup
-
-	switch category {
-	case "downloader":
-		text = "📥 *Downloader Commands*\n\n" +
-			"/play - Download audio from YouTube\n" +
-			"/ytv - Download video from YouTube\n" +
-			"/tiktok - Download TikTok video\n" +
-			"/ytmp3 - YouTube to MP3"
-		keyboard = tgbotapi.NewInlineKeyboardMarkup(
-			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("« Back", "back_menu"),
-			),
-		)
-	case "games":
-		text = "🎮 *Game Commands*\n\n" +
-			"/math - Math quiz game\n" +
-			"/tictactoe - Tic Tac Toe game\n" +
-			"/suit - Rock Paper Scissors\n" +
-			"/family100 - Family 100 game"
-		keyboard = tgbotapi.NewInlineKeyboardMarkup(
-			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("« Back", "back_menu"),
-			),
-		)
-	case "tools":
-		text = "🛠 *Tools Commands*\n\n" +
-			"/remini - Enhance image quality\n" +
-			"/getid - Get your user ID\n" +
-			"/sticker - Create sticker\n" +
-			"/toimg - Convert sticker to image\n\n" +
-			"*Stalker:*\n" +
-			"/igstalk - Instagram stalker\n" +
-			"/ghstalk - GitHub stalker\n\n" +
-			"*Maker:*\n" +
-			"/jadianime - Convert to anime style"
-		keyboard = tgbotapi.NewInlineKeyboardMarkup(
-			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("« Back", "back_menu"),
-			),
-		)
-	case "ai":
-		text = "🤖 *AI Commands*\n\n" +
-			"/ai - Chat with AI\n" +
-			"/ask - Ask AI anything\n" +
-			"/chatgpt - ChatGPT"
-		keyboard = tgbotapi.NewInlineKeyboardMarkup(
-			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("« Back", "back_menu"),
-			),
-		)
-	case "info":
-		text = "ℹ️ *Info Commands*\n\n" +
-			"/ping - Bot status\n" +
-			"/stats - Bot statistics\n" +
-			"/limit - Check your limit\n" +
-			"/profile - Your profile"
-		keyboard = tgbotapi.NewInlineKeyboardMarkup(
-			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("« Back", "back_menu"),
-			),
-		)
-	case "owner":
-		text = "👑 *Owner Commands*\n\n" +
-			"/broadcast - Broadcast message\n" +
-			"/addprem - Add premium user\n" +
-			"/stats - Bot statistics\n\n" +
-			"Restricted to bot owner only"
-		keyboard = tgbotapi.NewInlineKeyboardMarkup(
-			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("« Back", "back_menu"),
-			),
-		)
-	default:
-		text = "Category not found"
-		keyboard = tgbotapi.NewInlineKeyboardMarkup(
-			tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData("« Back", "back_menu"),
-			),
-		)
+func (h *Handler) handleMenuCallback(ctx *plugins.CallbackContext) error {
+	if ctx.Message == nil || len(ctx.Args) == 0 {
+		return nil
 	}
 
-	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, text)
-	edit.ReplyMarkup = &keyboard
-	edit.ParseMode = "Markdown"
-	h.api.Send(edit)
+	switch ctx.Args[0] {
+	case "stats":
+		return h.handleStats(ctx)
+	case "cat":
+		if len(ctx.Args) < 3 {
+			return nil
+		}
+		page, _ := strconv.Atoi(ctx.Args[2])
+		text, keyboard := h.categoryPage(ctx.Query.From.ID, ctx.Args[1], page)
+		return ctx.Edit(text, &keyboard)
+	case "home":
+		keyboard := h.menuKeyboard(ctx.Query.From.ID)
+		return ctx.Edit("*Sofinco Bot* - Your Telegram Assistant\n\nSelect a category below:", &keyboard)
+	}
+	return nil
 }
 
-func (h *Handler) handleBackToMenu(callback *tgbotapi.CallbackQuery) {
-	text := "*Sofinco Bot* - Your Telegram Assistant\n\nSelect a category below:"
+func (h *Handler) handleStats(ctx *plugins.CallbackContext) error {
+	text := "Statistics\n\nUsers: 0\nChats: 0\nCommands: 0"
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📥 Downloader", "cat_downloader"),
-			tgbotapi.NewInlineKeyboardButtonData("🎮 Games", "cat_games"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("🛠 Tools", "cat_tools"),
-			tgbotapi.NewInlineKeyboardButtonData("🤖 AI", "cat_ai"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("ℹ️ Info", "cat_info"),
-			tgbotapi.NewInlineKeyboardButtonData("👑 Owner", "cat_owner"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📊 Statistics", "stats"),
+			tgbotapi.NewInlineKeyboardButtonData("Back", menuData("home")),
 		),
 	)
 
-	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, text)
-	edit.ReplyMarkup = &keyboard
-	edit.ParseMode = "Markdown"
-	h.api.Send(edit)
+	return ctx.Edit(text, &keyboard)
 }
 
 func (h *Handler) sendMessage(chatID int64, text string) tgbotapi.Message {
@@ -466,8 +406,8 @@ func (h *Handler) handleLimit(msg *tgbotapi.Message, user *database.User) {
 		"Your Limit: %d\n"+
 		"Premium: %v\n\n"+
 		"Limit resets daily at 00:00 WIB\n"+
-		"Upgrade to premium for unlimited access!",
-		user.Limit, user.Premium)
+		"Daily limit: %d free, %d premium",
+		user.Limit, user.Premium, h.config.DefaultLimit, h.config.PremiumLimit)
 	
 	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
 	reply.ParseMode = "Markdown"
@@ -483,12 +423,24 @@ func (h *Handler) handleProfile(msg *tgbotapi.Message, user *database.User) {
 		"├ XP: %d\n"+
 		"├ Level: %d\n"+
 		"├ Limit: %d\n"+
-		"└ Premium: %v\n\n"+
-		"Registered: %v",
+		"└ Premium: %v\n\n",
 		user.FirstName, user.Username, user.ID,
-		user.Exp, user.Level, user.Limit, user.Premium,
-		user.RegisteredAt.Format("2006-01-02"))
-	
+		user.Exp, user.Level, user.Limit, user.Premium)
+
+	if user.Registered {
+		text += fmt.Sprintf("📝 Registered:\n"+
+			"├ Name: %s\n"+
+			"├ Age: %d\n"+
+			"└ Since: %s",
+			user.Name, user.Age, user.RegisteredAt.In(h.config.Location).Format("2006-01-02"))
+		// The serial confirms /unregister, so keep it out of groups
+		if msg.Chat.IsPrivate() {
+			text += fmt.Sprintf("\n\nSerial: `%s`", user.Serial)
+		}
+	} else {
+		text += "Registered: No\nRegister with /register name.age"
+	}
+
 	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
 	reply.ParseMode = "Markdown"
 	h.api.Send(reply)
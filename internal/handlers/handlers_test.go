@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/levouinse/sofinco-bot/internal/config"
+	"github.com/levouinse/sofinco-bot/internal/database"
+	"github.com/levouinse/sofinco-bot/internal/plugins"
+	"github.com/levouinse/sofinco-bot/internal/telegram"
+)
+
+// hookPlugin records how the handler called it.
+type hookPlugin struct {
+	plugins.BasePlugin
+	args       []string
+	media      *plugins.Media
+	edited     []int
+	member     []int64
+	myMember   []int64
+	executions int
+}
+
+func (p *hookPlugin) Commands() []string { return []string{"hooktest"} }
+
+func (p *hookPlugin) Execute(ctx *plugins.Context) error {
+	p.executions++
+	p.args = ctx.Args
+	p.media = ctx.Media
+	return nil
+}
+
+func (p *hookPlugin) OnEditedMessage(ctx *plugins.Context) error {
+	p.edited = append(p.edited, ctx.Message.MessageID)
+	return nil
+}
+
+func (p *hookPlugin) OnChatMember(ctx *plugins.ChatMemberContext) error {
+	p.member = append(p.member, ctx.Update.NewChatMember.User.ID)
+	return nil
+}
+
+func (p *hookPlugin) OnMyChatMember(ctx *plugins.ChatMemberContext) error {
+	p.myMember = append(p.myMember, ctx.Update.Chat.ID)
+	return nil
+}
+
+var hooks = &hookPlugin{}
+
+func init() {
+	plugins.Register(hooks)
+}
+
+func newTestHandler(t *testing.T) *Handler {
+	t.Helper()
+	*hooks = hookPlugin{}
+
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	cfg := &config.Config{Prefixes: []string{"/"}, Location: time.UTC, DefaultLimit: 30}
+	return New(context.Background(), telegram.NewRecorder(), db, cfg, nil)
+}
+
+func message(text string) *tgbotapi.Message {
+	return &tgbotapi.Message{
+		MessageID: 1,
+		From:      &tgbotapi.User{ID: 100, FirstName: "User100"},
+		Chat:      &tgbotapi.Chat{ID: 100, Type: "private"},
+		Text:      text,
+	}
+}
+
+func TestCaptionCommand(t *testing.T) {
+	h := newTestHandler(t)
+
+	msg := message("")
+	msg.Caption = "/hooktest Keep Case"
+	msg.Photo = []tgbotapi.PhotoSize{{FileID: "small"}, {FileID: "large"}}
+	h.HandleMessage(msg)
+
+	if hooks.executions != 1 {
+		t.Fatalf("plugin ran %d times, want 1", hooks.executions)
+	}
+	if want := []string{"Keep", "Case"}; !reflect.DeepEqual(hooks.args, want) {
+		t.Errorf("args = %q, want %q", hooks.args, want)
+	}
+	if !hooks.media.Is(plugins.MediaPhoto) || hooks.media.FileID != "large" {
+		t.Errorf("media = %+v, want the largest photo", hooks.media)
+	}
+
+	// A photo without a caption is not a command.
+	msg = message("")
+	msg.Photo = []tgbotapi.PhotoSize{{FileID: "large"}}
+	h.HandleMessage(msg)
+	if hooks.executions != 1 {
+		t.Errorf("plugin ran for a photo without caption")
+	}
+}
+
+func TestCommandUsesRepliedMedia(t *testing.T) {
+	h := newTestHandler(t)
+
+	msg := message("/hooktest")
+	msg.ReplyToMessage = &tgbotapi.Message{
+		MessageID: 2,
+		Video:     &tgbotapi.Video{FileID: "clip", MimeType: "video/mp4"},
+	}
+	h.HandleMessage(msg)
+
+	if hooks.media == nil || hooks.media.Type != plugins.MediaVideo || hooks.media.Message != msg.ReplyToMessage {
+		t.Errorf("media = %+v, want the video replied to", hooks.media)
+	}
+}
+
+func TestUpdateHooks(t *testing.T) {
+	h := newTestHandler(t)
+
+	edited := message("/hooktest edited")
+	edited.MessageID = 7
+	h.HandleEditedMessage(edited)
+	if !reflect.DeepEqual(hooks.edited, []int{7}) {
+		t.Errorf("edited hook saw %v, want [7]", hooks.edited)
+	}
+	if hooks.executions != 0 {
+		t.Error("an edited command was executed")
+	}
+
+	update := &tgbotapi.ChatMemberUpdated{
+		Chat:          tgbotapi.Chat{ID: -100, Type: "supergroup"},
+		From:          tgbotapi.User{ID: 1},
+		NewChatMember: tgbotapi.ChatMember{User: &tgbotapi.User{ID: 200}, Status: "member"},
+	}
+	h.HandleChatMember(update)
+	h.HandleMyChatMember(update)
+
+	if !reflect.DeepEqual(hooks.member, []int64{200}) {
+		t.Errorf("chat member hook saw %v, want [200]", hooks.member)
+	}
+	if !reflect.DeepEqual(hooks.myMember, []int64{-100}) {
+		t.Errorf("my chat member hook saw %v, want [-100]", hooks.myMember)
+	}
+}
@@ -0,0 +1,370 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/levouinse/sofinco-bot/internal/plugins"
+)
+
+// menuPageSize is the number of commands listed on one category page.
+const menuPageSize = 8
+
+// ownerTag marks plugins that only the bot owners may use. They are left
+// out of menus and help for everyone else.
+const ownerTag = "owner"
+
+// category describes how a plugin tag is shown in the menu.
+type category struct {
+	Tag   string
+	Emoji string
+	Title string
+}
+
+// categories lists the known tags in menu order. Tags that are not listed
+// here are shown after them with a generic title.
+var categories = []category{
+	{Tag: "downloader", Emoji: "📥", Title: "Downloader"},
+	{Tag: "game", Emoji: "🎮", Title: "Game"},
+	{Tag: "rpg", Emoji: "⚔️", Title: "RPG"},
+	{Tag: "tools", Emoji: "🛠", Title: "Tools"},
+	{Tag: "stalker", Emoji: "🔍", Title: "Stalker"},
+	{Tag: "maker", Emoji: "🎨", Title: "Maker"},
+	{Tag: "ai", Emoji: "🤖", Title: "AI"},
+	{Tag: "info", Emoji: "ℹ️", Title: "Info"},
+	{Tag: ownerTag, Emoji: "👑", Title: "Owner"},
+}
+
+// menuEntry is one command as shown in menus and help.
+type menuEntry struct {
+	Commands []string
+	Tags     []string
+	Help     string
+	Plugin   plugins.Plugin
+}
+
+// builtins are the commands handled by the switch in HandleMessage rather
+// than by a plugin.
+var builtins = []menuEntry{
+	{Commands: []string{"start"}, Tags: []string{"info"}, Help: "Show main menu"},
+	{Commands: []string{"menu"}, Tags: []string{"info"}, Help: "Display menu categories"},
+	{Commands: []string{"help"}, Tags: []string{"info"}, Help: "Show help for a command"},
+	{Commands: []string{"ping"}, Tags: []string{"info"}, Help: "Check bot status and uptime"},
+	{Commands: []string{"getid"}, Tags: []string{"info"}, Help: "Get your user and chat ID"},
+	{Commands: []string{"limit"}, Tags: []string{"info"}, Help: "Check your daily limit"},
+	{Commands: []string{"profile"}, Tags: []string{"info"}, Help: "View your profile and stats"},
+}
+
+func isBuiltin(name string) bool {
+	_, ok := findBuiltin(name)
+	return ok
+}
+
+func findBuiltin(name string) (menuEntry, bool) {
+	for _, entry := range builtins {
+		for _, cmd := range entry.Commands {
+			if cmd == name {
+				return entry, true
+			}
+		}
+	}
+	return menuEntry{}, false
+}
+
+// menuEntries returns the entries that userID may see.
+func (h *Handler) menuEntries(userID int64) []menuEntry {
+	entries := h.allEntries()
+	visible := entries[:0]
+	for _, entry := range entries {
+		if entry.ownerOnly() && !h.isOwner(userID) {
+			continue
+		}
+		visible = append(visible, entry)
+	}
+	return visible
+}
+
+// allEntries returns the built-in commands and every registered plugin.
+func (h *Handler) allEntries() []menuEntry {
+	entries := append([]menuEntry(nil), builtins...)
+	for _, plugin := range plugins.All() {
+		commands := registeredCommands(plugin)
+		if len(commands) == 0 {
+			continue
+		}
+		entries = append(entries, menuEntry{
+			Commands: commands,
+			Tags:     plugin.Tags(),
+			Help:     plugin.Help(),
+			Plugin:   plugin,
+		})
+	}
+	return entries
+}
+
+// registeredCommands returns the commands of plugin that still route to
+// it, since a later plugin may have taken over one of its names.
+func registeredCommands(plugin plugins.Plugin) []string {
+	var commands []string
+	for _, cmd := range plugin.Commands() {
+		if plugins.Registry[cmd] == plugin {
+			commands = append(commands, cmd)
+		}
+	}
+	return commands
+}
+
+func (e menuEntry) ownerOnly() bool {
+	return hasTag(e.Tags, ownerTag)
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// menuCategories returns the categories that have at least one entry.
+func menuCategories(entries []menuEntry) []category {
+	used := make(map[string]bool)
+	for _, entry := range entries {
+		for _, tag := range entry.Tags {
+			used[tag] = true
+		}
+	}
+
+	var result []category
+	for _, c := range categories {
+		if used[c.Tag] {
+			result = append(result, c)
+			delete(used, c.Tag)
+		}
+	}
+
+	var extra []string
+	for tag := range used {
+		extra = append(extra, tag)
+	}
+	sort.Strings(extra)
+	for _, tag := range extra {
+		result = append(result, category{Tag: tag, Emoji: "📂", Title: titleCase(tag)})
+	}
+	return result
+}
+
+func findCategory(tag string) category {
+	for _, c := range categories {
+		if c.Tag == tag {
+			return c
+		}
+	}
+	return category{Tag: tag, Emoji: "📂", Title: titleCase(tag)}
+}
+
+// menuCallback is the callback prefix of the built-in menu buttons.
+const menuCallback = "menu"
+
+// menuData builds the callback data of a menu button.
+func menuData(args ...string) string {
+	data, err := plugins.CallbackData(menuCallback, args...)
+	if err != nil {
+		log.Printf("Invalid menu button %v: %v", args, err)
+	}
+	return data
+}
+
+// menuKeyboard lays out the category buttons two per row.
+func (h *Handler) menuKeyboard(userID int64) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, c := range menuCategories(h.menuEntries(userID)) {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(c.Emoji+" "+c.Title, menuData("cat", c.Tag, "0")))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("📊 Statistics", menuData("stats")),
+	))
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// categoryPage renders one page of the category tag.
+func (h *Handler) categoryPage(userID int64, tag string, page int) (string, tgbotapi.InlineKeyboardMarkup) {
+	var entries []menuEntry
+	for _, entry := range h.menuEntries(userID) {
+		if hasTag(entry.Tags, tag) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Commands[0] < entries[j].Commands[0]
+	})
+
+	back := tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("« Back", menuData("home")))
+	if len(entries) == 0 {
+		return "Category not found", tgbotapi.NewInlineKeyboardMarkup(back)
+	}
+
+	pages := (len(entries) + menuPageSize - 1) / menuPageSize
+	if page < 0 {
+		page = 0
+	}
+	if page >= pages {
+		page = pages - 1
+	}
+	start := page * menuPageSize
+	end := start + menuPageSize
+	if end > len(entries) {
+		end = len(entries)
+	}
+
+	c := findCategory(tag)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s *%s Commands*\n\n", c.Emoji, c.Title)
+	for _, entry := range entries[start:end] {
+		fmt.Fprintf(&b, "/%s - %s\n", escapeMarkdown(entry.Commands[0]), escapeMarkdown(entry.Help))
+	}
+	if pages > 1 {
+		fmt.Fprintf(&b, "\nPage %d/%d", page+1, pages)
+	}
+	b.WriteString("\nUse /help <command> for details.")
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("‹ Prev", menuData("cat", tag, strconv.Itoa(page-1))))
+	}
+	if page < pages-1 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("Next ›", menuData("cat", tag, strconv.Itoa(page+1))))
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	rows = append(rows, back)
+	return b.String(), tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// commandHelp renders the detail view of a single command, or reports
+// false when userID may not see it.
+func (h *Handler) commandHelp(userID int64, name string) (string, bool) {
+	var entry menuEntry
+	if plugin, ok := plugins.Registry[name]; ok {
+		entry = menuEntry{Commands: registeredCommands(plugin), Tags: plugin.Tags(), Help: plugin.Help(), Plugin: plugin}
+	} else if builtin, ok := findBuiltin(name); ok {
+		entry = builtin
+	} else {
+		return "", false
+	}
+	if entry.ownerOnly() && !h.isOwner(userID) {
+		return "", false
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "ℹ️ *Help: /%s*\n\n%s\n", escapeMarkdown(name), escapeMarkdown(entry.Help))
+
+	var aliases []string
+	for _, cmd := range entry.Commands {
+		if cmd != name {
+			aliases = append(aliases, "/"+escapeMarkdown(cmd))
+		}
+	}
+	if len(aliases) > 0 {
+		fmt.Fprintf(&b, "\n*Aliases:* %s", strings.Join(aliases, ", "))
+	}
+
+	var titles []string
+	for _, tag := range entry.Tags {
+		titles = append(titles, findCategory(tag).Title)
+	}
+	if len(titles) > 0 {
+		fmt.Fprintf(&b, "\n*Category:* %s", strings.Join(titles, ", "))
+	}
+
+	if p := entry.Plugin; p != nil {
+		var reqs []string
+		if p.RequireLimit() {
+			reqs = append(reqs, fmt.Sprintf("%d limit", p.LimitCost()))
+		}
+		if p.RequireRegistered() {
+			reqs = append(reqs, "registration")
+		}
+		if p.RequirePremium() {
+			reqs = append(reqs, "premium")
+		}
+		if p.RequireGroup() || p.RequireAdmin() {
+			reqs = append(reqs, "group only")
+		}
+		if p.RequireAdmin() {
+			reqs = append(reqs, "group admin")
+		}
+		if entry.ownerOnly() {
+			reqs = append(reqs, "owner")
+		}
+		if len(reqs) > 0 {
+			fmt.Fprintf(&b, "\n*Requires:* %s", strings.Join(reqs, ", "))
+		}
+	}
+
+	return b.String(), true
+}
+
+func (h *Handler) handleHelp(msg *tgbotapi.Message, args []string) {
+	if len(args) == 0 {
+		reply := tgbotapi.NewMessage(msg.Chat.ID, "*Sofinco Bot* - Your Telegram Assistant\n\n"+
+			"Select a category below, or use /help <command> for details.")
+		reply.ParseMode = "Markdown"
+		reply.ReplyMarkup = h.menuKeyboard(msg.From.ID)
+		h.api.Send(reply)
+		return
+	}
+
+	name := strings.ToLower(strings.TrimLeft(args[0], "/.!#"))
+	if i := strings.IndexByte(name, '@'); i >= 0 {
+		name = name[:i]
+	}
+
+	text, ok := h.commandHelp(msg.From.ID, name)
+	if !ok {
+		h.sendMessage(msg.Chat.ID, fmt.Sprintf("Command /%s not found. Use /menu to see available commands.", name))
+		return
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	reply.ParseMode = "Markdown"
+	h.api.Send(reply)
+}
+
+func titleCase(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func (h *Handler) isOwner(userID int64) bool {
+	for _, ownerID := range h.config.OwnerIDs {
+		if userID == ownerID {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeMarkdown escapes the characters that have a meaning in Telegram's
+// legacy Markdown.
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer("_", "\\_", "*", "\\*", "`", "\\`", "[", "\\[").Replace(s)
+}
@@ -0,0 +1,97 @@
+package i18n
+
+import "sort"
+
+// commandHelp holds translated command descriptions, keyed by language and
+// then by command. Commands without an entry use the plugin's Help().
+var commandHelp = map[string]map[string]string{
+	"id": {
+		"start":     "Tampilkan menu utama",
+		"menu":      "Tampilkan kategori menu",
+		"help":      "Bantuan untuk sebuah command",
+		"ping":      "Cek status dan uptime bot",
+		"getid":     "Lihat ID user dan chat",
+		"limit":     "Cek limit harian kamu",
+		"profile":   "Lihat profil dan statistik kamu",
+		"ai":        "Ngobrol dengan AI",
+		"instagram": "Download video/foto Instagram",
+		"facebook":  "Download video Facebook",
+		"twitter":   "Download video Twitter/X",
+		"spotify":   "Download lagu Spotify",
+		"mediafire": "Download file dari MediaFire",
+		"play":      "Download audio dari YouTube",
+		"tiktok":    "Download video TikTok",
+		"math":      "Game kuis matematika",
+		"tictactoe": "Main Tic Tac Toe",
+		"suit":      "Game suit batu gunting kertas",
+		"family100": "Game Family 100",
+		"addprem":   "Tambah user premium (khusus owner)",
+		"exec":      "Jalankan perintah shell (khusus owner)",
+		"broadcast": "Kirim pesan ke semua user (khusus owner)",
+		"delprem":   "Hapus user premium (khusus owner)",
+		"listprem":  "Daftar user premium (khusus owner)",
+		"setprefix": "Atur prefix command grup ini",
+		"stats":     "Lihat statistik bot",
+		"remini":    "Perjelas kualitas gambar",
+		"sticker":   "Ubah gambar/video jadi stiker",
+		"toimg":     "Ubah stiker jadi gambar",
+		"qrcode":    "Buat QR code",
+		"translate": "Terjemahkan teks",
+		"wikipedia": "Cari di Wikipedia",
+		"calc":      "Hitung ekspresi matematika",
+		"ghstalk":   "Lihat profil GitHub",
+		"igstalk":   "Lihat profil Instagram",
+		"jadianime": "Ubah foto jadi gaya anime",
+
+		"levelup":     "Lihat progres level kamu",
+		"leaderboard": "Lihat peringkat user teratas",
+		"balance":     "Lihat money dan riwayat transaksi",
+		"transfer":    "Kirim money ke user lain",
+		"shop":        "Beli limit atau premium dengan money",
+		"daily":       "Klaim hadiah harian",
+		"weekly":      "Klaim hadiah mingguan",
+		"monthly":     "Klaim hadiah bulanan",
+		"register":    "Daftar dengan nama dan umur",
+		"unregister":  "Hapus pendaftaran",
+
+		"asahotak":    "Game asah otak",
+		"siapakahaku": "Game tebak siapa aku",
+		"tebaklagu":   "Tebak judul lagu",
+		"tebakanime":  "Tebak nama anime dari gambar",
+		"tebakgambar": "Tebak kata dari gambar",
+		"tebakkata":   "Tebak kata dari petunjuk",
+	},
+	"en": {
+		"start":       "Show main menu",
+		"menu":        "Display menu categories",
+		"help":        "Show help for a command",
+		"asahotak":    "Brain teaser game",
+		"siapakahaku": "Guess who game",
+		"tebaklagu":   "Guess the song title",
+		"tebakanime":  "Guess the anime from a picture",
+		"tebakgambar": "Guess the word from a picture",
+		"tebakkata":   "Guess the word from a clue",
+	},
+}
+
+// Languages returns the supported language codes, starting with
+// DefaultLanguage.
+func Languages() []string {
+	var others []string
+	for lang := range messages {
+		if lang != DefaultLanguage {
+			others = append(others, lang)
+		}
+	}
+	sort.Strings(others)
+	return append([]string{DefaultLanguage}, others...)
+}
+
+// CommandHelp returns the description of command in lang, or fallback when
+// there is no translation.
+func CommandHelp(lang, command, fallback string) string {
+	if text, ok := commandHelp[Language(lang)][command]; ok {
+		return text
+	}
+	return fallback
+}
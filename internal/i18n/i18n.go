@@ -0,0 +1,61 @@
+// Package i18n holds the translated texts shown to users.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultLanguage is used when a user's language has no translation.
+const DefaultLanguage = "id"
+
+var messages = map[string]map[string]string{
+	"id": {
+		"require.premium": "💎 Command ini khusus untuk user premium.\nHubungi owner untuk upgrade ke premium.",
+		"require.group":   "👥 Command ini hanya bisa digunakan di dalam grup.",
+		"require.admin":   "🛡 Command ini hanya untuk admin grup.",
+		"require.limit":   "❌ Limit kamu habis! Tunggu reset harian atau upgrade ke premium untuk limit lebih besar.",
+		"admin.unknown":   "⚠️ Gagal memeriksa daftar admin grup, coba lagi nanti.",
+		"limit.low":       "⚠️ Sisa limit kamu tinggal %d.",
+
+		"require.registered": "📝 Kamu harus daftar dulu untuk memakai command ini.\nDaftar dengan /register nama.umur",
+	},
+	"en": {
+		"require.premium": "💎 This command is for premium users only.\nContact the owner to upgrade to premium.",
+		"require.group":   "👥 This command can only be used in groups.",
+		"require.admin":   "🛡 This command is for group admins only.",
+		"require.limit":   "❌ You have run out of limit! Wait for the daily reset or upgrade to premium for a bigger limit.",
+		"admin.unknown":   "⚠️ Could not check the group admin list, please try again later.",
+		"limit.low":       "⚠️ You only have %d limit left.",
+
+		"require.registered": "📝 You need to register before using this command.\nRegister with /register name.age",
+	},
+}
+
+// Language normalizes a Telegram language code such as "en-US" to one of
+// the supported languages.
+func Language(code string) string {
+	code = strings.ToLower(code)
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	if _, ok := messages[code]; ok {
+		return code
+	}
+	return DefaultLanguage
+}
+
+// T returns the text for key in lang, formatted with args. Missing keys
+// fall back to the default language and then to the key itself.
+func T(lang, key string, args ...interface{}) string {
+	text, ok := messages[Language(lang)][key]
+	if !ok {
+		if text, ok = messages[DefaultLanguage][key]; !ok {
+			text = key
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
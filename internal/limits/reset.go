@@ -0,0 +1,61 @@
+// Package limits keeps the daily command limits of users topped up.
+package limits
+
+import (
+	"log"
+	"time"
+
+	"github.com/levouinse/sofinco-bot/internal/config"
+	"github.com/levouinse/sofinco-bot/internal/database"
+	"github.com/levouinse/sofinco-bot/internal/scheduler"
+)
+
+// JobName is the scheduler job that resets limits at midnight.
+const JobName = "limits.reset"
+
+// Resetter restores daily limits at midnight in the configured timezone.
+type Resetter struct {
+	db  *database.Database
+	cfg *config.Config
+}
+
+func NewResetter(db *database.Database, cfg *config.Config) *Resetter {
+	return &Resetter{db: db, cfg: cfg}
+}
+
+// Schedule resets limits right away if the bot missed a midnight while it
+// was offline, then registers a job that resets them at every midnight.
+func (r *Resetter) Schedule(s *scheduler.Scheduler) error {
+	if err := r.ResetIfDue(time.Now()); err != nil {
+		log.Printf("Failed to reset daily limits: %v", err)
+	}
+
+	scheduler.Handle(JobName, func(ctx *scheduler.Context) error {
+		return r.ResetIfDue(time.Now())
+	})
+	return s.Every(JobName, JobName, "0 0 * * *", nil)
+}
+
+// ResetIfDue resets limits unless that already happened today.
+func (r *Resetter) ResetIfDue(now time.Time) error {
+	day := StartOfDay(now, r.cfg.Location)
+	reset, err := r.db.ResetLimits(day, r.cfg.DefaultLimit, r.cfg.PremiumLimit)
+	if err != nil {
+		return err
+	}
+	if reset {
+		log.Printf("Daily limits reset for %s", day.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// StartOfDay returns midnight of the day t falls on in loc.
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// NextDay returns the first midnight in loc after t.
+func NextDay(t time.Time, loc *time.Location) time.Time {
+	return StartOfDay(t, loc).AddDate(0, 0, 1)
+}
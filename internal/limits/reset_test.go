@@ -0,0 +1,127 @@
+package limits
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/levouinse/sofinco-bot/internal/config"
+	"github.com/levouinse/sofinco-bot/internal/database"
+)
+
+var wib = time.FixedZone("WIB", 7*60*60)
+
+func TestStartOfDay(t *testing.T) {
+	tests := []struct {
+		now   time.Time
+		start time.Time
+		next  time.Time
+	}{
+		{
+			now:   time.Date(2024, 3, 10, 16, 59, 0, 0, time.UTC),
+			start: time.Date(2024, 3, 10, 0, 0, 0, 0, wib),
+			next:  time.Date(2024, 3, 11, 0, 0, 0, 0, wib),
+		},
+		{
+			// 17:00 UTC is already midnight of the next day in WIB.
+			now:   time.Date(2024, 3, 10, 17, 0, 0, 0, time.UTC),
+			start: time.Date(2024, 3, 11, 0, 0, 0, 0, wib),
+			next:  time.Date(2024, 3, 12, 0, 0, 0, 0, wib),
+		},
+		{
+			now:   time.Date(2024, 12, 31, 23, 30, 0, 0, wib),
+			start: time.Date(2024, 12, 31, 0, 0, 0, 0, wib),
+			next:  time.Date(2025, 1, 1, 0, 0, 0, 0, wib),
+		},
+	}
+
+	for _, tt := range tests {
+		if got := StartOfDay(tt.now, wib); !got.Equal(tt.start) {
+			t.Errorf("StartOfDay(%v) = %v, want %v", tt.now, got, tt.start)
+		}
+		if got := NextDay(tt.now, wib); !got.Equal(tt.next) {
+			t.Errorf("NextDay(%v) = %v, want %v", tt.now, got, tt.next)
+		}
+	}
+}
+
+func TestResetIfDue(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	users := []*database.User{
+		{ID: 1, Limit: 2},
+		{ID: 2, Limit: 5, Premium: true},
+		{ID: 3, Limit: 50},
+		{ID: 4, Limit: 5, Premium: true, PremiumUntil: time.Date(2024, 3, 9, 20, 0, 0, 0, wib)},
+	}
+	for _, u := range users {
+		if err := db.SaveUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewResetter(db, &config.Config{Location: wib, DefaultLimit: 30, PremiumLimit: 1000})
+	check := func(step string, want map[int64]int) {
+		t.Helper()
+		for id, limit := range want {
+			u, err := db.GetUser(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if u.Limit != limit {
+				t.Errorf("%s: user %d limit = %d, want %d", step, id, u.Limit, limit)
+			}
+		}
+	}
+	spend := func(id int64, cost int) {
+		t.Helper()
+		if _, err := db.ChargeLimit(id, cost); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The first run after startup catches up on the missed reset. Users
+	// above their quota keep what they have, and premium that lapsed
+	// before the day started only gets the free quota.
+	day1 := time.Date(2024, 3, 10, 9, 0, 0, 0, wib)
+	if err := r.ResetIfDue(day1); err != nil {
+		t.Fatal(err)
+	}
+	check("catch-up", map[int64]int{1: 30, 2: 1000, 3: 50, 4: 30})
+	if u, err := db.GetUser(4); err != nil || u.Premium {
+		t.Errorf("lapsed premium not revoked: %+v, %v", u, err)
+	}
+
+	// Another run on the same day, for example after a restart, must not
+	// top anyone up again.
+	spend(1, 10)
+	if err := r.ResetIfDue(day1.Add(14*time.Hour + 59*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	check("same day", map[int64]int{1: 20})
+
+	// Midnight WIB starts a new day.
+	if err := r.ResetIfDue(time.Date(2024, 3, 11, 0, 0, 0, 0, wib)); err != nil {
+		t.Fatal(err)
+	}
+	check("next day", map[int64]int{1: 30})
+
+	last, err := db.LastLimitReset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 3, 11, 0, 0, 0, 0, wib); !last.Equal(want) {
+		t.Errorf("last reset = %v, want %v", last, want)
+	}
+
+	// A clock that went backwards must not reset an earlier day.
+	spend(1, 10)
+	if err := r.ResetIfDue(day1); err != nil {
+		t.Fatal(err)
+	}
+	check("earlier day", map[int64]int{1: 20})
+}
@@ -0,0 +1,84 @@
+package plugins
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/levouinse/sofinco-bot/internal/telegram"
+)
+
+// AdminCache remembers the administrators of each group for a while so
+// admin checks don't call getChatAdministrators on every command.
+type AdminCache struct {
+	ttl   time.Duration
+	mu    sync.Mutex
+	chats map[int64]adminEntry
+}
+
+type adminEntry struct {
+	admins  map[int64]bool
+	expires time.Time
+}
+
+// Admins is the cache used by the CheckRequirements middleware.
+var Admins = NewAdminCache(10 * time.Minute)
+
+func NewAdminCache(ttl time.Duration) *AdminCache {
+	return &AdminCache{
+		ttl:   ttl,
+		chats: make(map[int64]adminEntry),
+	}
+}
+
+// IsAdmin reports whether userID administers chatID, fetching the admin
+// list from Telegram when it is not cached.
+func (c *AdminCache) IsAdmin(api telegram.Sender, chatID, userID int64) (bool, error) {
+	c.mu.Lock()
+	entry, ok := c.chats[chatID]
+	c.mu.Unlock()
+
+	if !ok || time.Now().After(entry.expires) {
+		admins, err := fetchAdmins(api, chatID)
+		if err != nil {
+			return false, err
+		}
+		entry = adminEntry{admins: admins, expires: time.Now().Add(c.ttl)}
+
+		c.mu.Lock()
+		c.chats[chatID] = entry
+		c.mu.Unlock()
+	}
+
+	return entry.admins[userID], nil
+}
+
+// Invalidate drops the cached admin list of chatID.
+func (c *AdminCache) Invalidate(chatID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.chats, chatID)
+}
+
+func fetchAdmins(api telegram.Sender, chatID int64) (map[int64]bool, error) {
+	resp, err := api.Request(tgbotapi.ChatAdministratorsConfig{
+		ChatConfig: tgbotapi.ChatConfig{ChatID: chatID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var members []tgbotapi.ChatMember
+	if err := json.Unmarshal(resp.Result, &members); err != nil {
+		return nil, err
+	}
+
+	admins := make(map[int64]bool, len(members))
+	for _, m := range members {
+		if m.User != nil {
+			admins[m.User.ID] = true
+		}
+	}
+	return admins, nil
+}
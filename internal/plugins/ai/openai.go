@@ -6,7 +6,6 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/levouinse/sofinco-bot/internal/plugins"
@@ -29,10 +28,11 @@ func (p *AIPlugin) Execute(ctx *plugins.Context) error {
 	if len(ctx.Args) == 0 {
 		msg := tgbotapi.NewMessage(ctx.Message.Chat.ID, "Masukkan pertanyaan!\n\nContoh:\n/ai apa itu golang?")
 		ctx.API.Send(msg)
+		ctx.NoCharge()
 		return nil
 	}
 
-	question := strings.Join(ctx.Args, " ")
+	question := ctx.RawArgs
 	
 	waitMsg := tgbotapi.NewMessage(ctx.Message.Chat.ID, "🤔 Thinking...")
 	sent, _ := ctx.API.Send(waitMsg)
@@ -64,10 +64,5 @@ func (p *AIPlugin) Execute(ctx *plugins.Context) error {
 	reply.ReplyToMessageID = ctx.Message.MessageID
 	ctx.API.Send(reply)
 
-	if !ctx.User.Premium {
-		ctx.User.Limit--
-		ctx.DB.SaveUser(ctx.User)
-	}
-
 	return nil
 }
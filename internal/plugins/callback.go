@@ -0,0 +1,185 @@
+package plugins
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/levouinse/sofinco-bot/internal/config"
+	"github.com/levouinse/sofinco-bot/internal/database"
+	"github.com/levouinse/sofinco-bot/internal/scheduler"
+	"github.com/levouinse/sofinco-bot/internal/telegram"
+)
+
+// MaxCallbackData is the most bytes Telegram keeps in a button's
+// callback data.
+const MaxCallbackData = 64
+
+// callbackSeparator joins the prefix, arguments and signature of callback
+// data, e.g. "ttt|move|4|<sig>".
+const callbackSeparator = "|"
+
+// callbackSigLen is the length of the encoded signature. Eight bytes of
+// HMAC are plenty to stop users from crafting their own button presses
+// while leaving most of the 64 bytes to the data.
+const callbackSigLen = 11
+
+var (
+	ErrCallbackTooLong = errors.New("callback data exceeds 64 bytes")
+	ErrCallbackInvalid = errors.New("callback data contains the separator")
+)
+
+// CallbackHandler is implemented by plugins that use inline buttons.
+// Callback data built with CallbackData(prefix, ...) for the returned
+// prefix is routed to HandleCallback.
+type CallbackHandler interface {
+	CallbackPrefix() string
+	HandleCallback(ctx *CallbackContext) error
+}
+
+// CallbackFunc handles a routed callback query.
+type CallbackFunc func(ctx *CallbackContext) error
+
+// CallbackContext is passed to callback handlers.
+type CallbackContext struct {
+	// Ctx is cancelled when the bot starts shutting down.
+	Ctx       context.Context
+	API       telegram.Sender
+	DB        *database.Database
+	Config    *config.Config
+	Scheduler *scheduler.Scheduler
+	Query     *tgbotapi.CallbackQuery
+	// Message is the message holding the button. It is nil for buttons
+	// on inline-mode messages.
+	Message *tgbotapi.Message
+	User    *database.User
+	Prefix  string
+	Args    []string
+
+	answered bool
+}
+
+// Answered reports whether the handler already answered the query.
+func (ctx *CallbackContext) Answered() bool {
+	return ctx.answered
+}
+
+// Answer shows text as a short toast. An empty text just stops the
+// loading indicator on the button.
+func (ctx *CallbackContext) Answer(text string) error {
+	ctx.answered = true
+	_, err := ctx.API.Request(tgbotapi.NewCallback(ctx.Query.ID, text))
+	return err
+}
+
+// Alert shows text in a dialog the user has to dismiss.
+func (ctx *CallbackContext) Alert(text string) error {
+	ctx.answered = true
+	_, err := ctx.API.Request(tgbotapi.NewCallbackWithAlert(ctx.Query.ID, text))
+	return err
+}
+
+// Edit replaces the text and keyboard of the message holding the button.
+// The text is sent as Markdown.
+func (ctx *CallbackContext) Edit(text string, keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	if ctx.Message == nil {
+		return errors.New("callback has no message to edit")
+	}
+	edit := tgbotapi.NewEditMessageText(ctx.Message.Chat.ID, ctx.Message.MessageID, text)
+	edit.ParseMode = "Markdown"
+	edit.ReplyMarkup = keyboard
+	_, err := ctx.API.Send(edit)
+	return err
+}
+
+// EditKeyboard replaces only the keyboard of the message holding the
+// button. A nil keyboard removes it.
+func (ctx *CallbackContext) EditKeyboard(keyboard *tgbotapi.InlineKeyboardMarkup) error {
+	if ctx.Message == nil {
+		return errors.New("callback has no message to edit")
+	}
+	if keyboard == nil {
+		keyboard = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	}
+	edit := tgbotapi.NewEditMessageReplyMarkup(ctx.Message.Chat.ID, ctx.Message.MessageID, *keyboard)
+	_, err := ctx.API.Send(edit)
+	return err
+}
+
+var (
+	callbackMu     sync.RWMutex
+	callbacks      = make(map[string]CallbackFunc)
+	callbackSecret []byte
+)
+
+// HandleCallback routes callback data with prefix to fn. Plugins that
+// implement CallbackHandler are registered automatically by Register.
+func HandleCallback(prefix string, fn CallbackFunc) {
+	callbackMu.Lock()
+	defer callbackMu.Unlock()
+	callbacks[prefix] = fn
+}
+
+// CallbackRoute returns the handler registered for prefix.
+func CallbackRoute(prefix string) (CallbackFunc, bool) {
+	callbackMu.RLock()
+	defer callbackMu.RUnlock()
+	fn, ok := callbacks[prefix]
+	return fn, ok
+}
+
+// SetCallbackSecret sets the key callback data is signed with.
+func SetCallbackSecret(secret []byte) {
+	callbackMu.Lock()
+	defer callbackMu.Unlock()
+	callbackSecret = append([]byte(nil), secret...)
+}
+
+// CallbackData builds signed callback data for a button. It fails when the
+// result would not fit in 64 bytes or an argument contains "|".
+func CallbackData(prefix string, args ...string) (string, error) {
+	parts := append([]string{prefix}, args...)
+	for _, part := range parts {
+		if strings.Contains(part, callbackSeparator) {
+			return "", ErrCallbackInvalid
+		}
+	}
+
+	payload := strings.Join(parts, callbackSeparator)
+	data := payload + callbackSeparator + signCallback(payload)
+	if len(data) > MaxCallbackData {
+		return "", fmt.Errorf("%w: %q", ErrCallbackTooLong, payload)
+	}
+	return data, nil
+}
+
+// ParseCallbackData verifies data built by CallbackData and splits it into
+// its prefix and arguments.
+func ParseCallbackData(data string) (prefix string, args []string, ok bool) {
+	i := strings.LastIndex(data, callbackSeparator)
+	if i < 0 {
+		return "", nil, false
+	}
+	payload, sig := data[:i], data[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(signCallback(payload))) {
+		return "", nil, false
+	}
+
+	parts := strings.Split(payload, callbackSeparator)
+	return parts[0], parts[1:], true
+}
+
+func signCallback(payload string) string {
+	callbackMu.RLock()
+	mac := hmac.New(sha256.New, callbackSecret)
+	callbackMu.RUnlock()
+
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:8])[:callbackSigLen]
+}
@@ -6,7 +6,6 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/levouinse/sofinco-bot/internal/plugins"
@@ -45,10 +44,11 @@ func (p *PlayPlugin) RequireLimit() bool { return true }
 func (p *PlayPlugin) Execute(ctx *plugins.Context) error {
 	if len(ctx.Args) == 0 {
 		ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, "Masukkan judul/link YouTube!\n\nContoh:\n/play taylor swift"))
+		ctx.NoCharge()
 		return nil
 	}
 
-	query := strings.Join(ctx.Args, " ")
+	query := ctx.RawArgs
 	
 	waitMsg := tgbotapi.NewMessage(ctx.Message.Chat.ID, "Tunggu sebentar...")
 	sent, _ := ctx.API.Send(waitMsg)
@@ -114,10 +114,5 @@ func (p *PlayPlugin) Execute(ctx *plugins.Context) error {
 	audio.Performer = vid.Author.Name
 	ctx.API.Send(audio)
 
-	if !ctx.User.Premium {
-		ctx.User.Limit--
-		ctx.DB.SaveUser(ctx.User)
-	}
-
 	return nil
 }
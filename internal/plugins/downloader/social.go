@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/levouinse/sofinco-bot/internal/plugins"
@@ -28,14 +29,15 @@ func (p *InstagramPlugin) RequireLimit() bool { return true }
 func (p *InstagramPlugin) Execute(ctx *plugins.Context) error {
 	if len(ctx.Args) == 0 {
 		ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, "Usage: /instagram <url>"))
+		ctx.NoCharge()
 		return nil
 	}
 
-	url := ctx.Args[0]
+	link := ctx.Args[0]
 	msg := tgbotapi.NewMessage(ctx.Message.Chat.ID, "⏳ Downloading...")
 	sent, _ := ctx.API.Send(msg)
 
-	apiURL := fmt.Sprintf("https://api.betabotz.eu.org/api/download/instagram?url=%s&apikey=%s", url, ctx.Config.APIKey)
+	apiURL := fmt.Sprintf("https://api.betabotz.eu.org/api/download/instagram?url=%s&apikey=%s", url.QueryEscape(link), ctx.Config.APIKey)
 	resp, err := http.Get(apiURL)
 	if err != nil {
 		edit := tgbotapi.NewEditMessageText(ctx.Message.Chat.ID, sent.MessageID, "❌ Failed to download")
@@ -63,6 +65,7 @@ func (p *InstagramPlugin) Execute(ctx *plugins.Context) error {
 	if !apiResponse.Status || len(apiResponse.Result) == 0 {
 		edit := tgbotapi.NewEditMessageText(ctx.Message.Chat.ID, sent.MessageID, "❌ No media found")
 		ctx.API.Send(edit)
+		ctx.NoCharge()
 		return nil
 	}
 
@@ -101,14 +104,15 @@ func (p *FacebookPlugin) RequireLimit() bool { return true }
 func (p *FacebookPlugin) Execute(ctx *plugins.Context) error {
 	if len(ctx.Args) == 0 {
 		ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, "Usage: /facebook <url>"))
+		ctx.NoCharge()
 		return nil
 	}
 
-	url := ctx.Args[0]
+	link := ctx.Args[0]
 	msg := tgbotapi.NewMessage(ctx.Message.Chat.ID, "⏳ Downloading...")
 	sent, _ := ctx.API.Send(msg)
 
-	apiURL := fmt.Sprintf("https://api.betabotz.eu.org/api/download/facebook?url=%s&apikey=%s", url, ctx.Config.APIKey)
+	apiURL := fmt.Sprintf("https://api.betabotz.eu.org/api/download/facebook?url=%s&apikey=%s", url.QueryEscape(link), ctx.Config.APIKey)
 	resp, err := http.Get(apiURL)
 	if err != nil {
 		edit := tgbotapi.NewEditMessageText(ctx.Message.Chat.ID, sent.MessageID, "❌ Failed to download")
@@ -137,6 +141,7 @@ func (p *FacebookPlugin) Execute(ctx *plugins.Context) error {
 	if !apiResponse.Status {
 		edit := tgbotapi.NewEditMessageText(ctx.Message.Chat.ID, sent.MessageID, "❌ Failed to download")
 		ctx.API.Send(edit)
+		ctx.NoCharge()
 		return nil
 	}
 
@@ -173,14 +178,15 @@ func (p *TwitterPlugin) RequireLimit() bool { return true }
 func (p *TwitterPlugin) Execute(ctx *plugins.Context) error {
 	if len(ctx.Args) == 0 {
 		ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, "Usage: /twitter <url>"))
+		ctx.NoCharge()
 		return nil
 	}
 
-	url := ctx.Args[0]
+	link := ctx.Args[0]
 	msg := tgbotapi.NewMessage(ctx.Message.Chat.ID, "⏳ Downloading...")
 	sent, _ := ctx.API.Send(msg)
 
-	apiURL := fmt.Sprintf("https://api.betabotz.eu.org/api/download/twitter?url=%s&apikey=%s", url, ctx.Config.APIKey)
+	apiURL := fmt.Sprintf("https://api.betabotz.eu.org/api/download/twitter?url=%s&apikey=%s", url.QueryEscape(link), ctx.Config.APIKey)
 	resp, err := http.Get(apiURL)
 	if err != nil {
 		edit := tgbotapi.NewEditMessageText(ctx.Message.Chat.ID, sent.MessageID, "❌ Failed to download")
@@ -208,6 +214,7 @@ func (p *TwitterPlugin) Execute(ctx *plugins.Context) error {
 	if !apiResponse.Status {
 		edit := tgbotapi.NewEditMessageText(ctx.Message.Chat.ID, sent.MessageID, "❌ Failed to download")
 		ctx.API.Send(edit)
+		ctx.NoCharge()
 		return nil
 	}
 
@@ -239,14 +246,15 @@ func (p *SpotifyPlugin) RequireLimit() bool { return true }
 func (p *SpotifyPlugin) Execute(ctx *plugins.Context) error {
 	if len(ctx.Args) == 0 {
 		ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, "Usage: /spotify <url>"))
+		ctx.NoCharge()
 		return nil
 	}
 
-	url := ctx.Args[0]
+	link := ctx.Args[0]
 	msg := tgbotapi.NewMessage(ctx.Message.Chat.ID, "⏳ Downloading...")
 	sent, _ := ctx.API.Send(msg)
 
-	apiURL := fmt.Sprintf("https://api.betabotz.eu.org/api/download/spotify?url=%s&apikey=%s", url, ctx.Config.APIKey)
+	apiURL := fmt.Sprintf("https://api.betabotz.eu.org/api/download/spotify?url=%s&apikey=%s", url.QueryEscape(link), ctx.Config.APIKey)
 	resp, err := http.Get(apiURL)
 	if err != nil {
 		edit := tgbotapi.NewEditMessageText(ctx.Message.Chat.ID, sent.MessageID, "❌ Failed to download")
@@ -276,6 +284,7 @@ func (p *SpotifyPlugin) Execute(ctx *plugins.Context) error {
 	if !apiResponse.Status {
 		edit := tgbotapi.NewEditMessageText(ctx.Message.Chat.ID, sent.MessageID, "❌ Failed to download")
 		ctx.API.Send(edit)
+		ctx.NoCharge()
 		return nil
 	}
 
@@ -309,14 +318,15 @@ func (p *MediaFirePlugin) RequireLimit() bool { return true }
 func (p *MediaFirePlugin) Execute(ctx *plugins.Context) error {
 	if len(ctx.Args) == 0 {
 		ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, "Usage: /mediafire <url>"))
+		ctx.NoCharge()
 		return nil
 	}
 
-	url := ctx.Args[0]
+	link := ctx.Args[0]
 	msg := tgbotapi.NewMessage(ctx.Message.Chat.ID, "⏳ Getting file info...")
 	sent, _ := ctx.API.Send(msg)
 
-	apiURL := fmt.Sprintf("https://api.betabotz.eu.org/api/download/mediafire?url=%s&apikey=%s", url, ctx.Config.APIKey)
+	apiURL := fmt.Sprintf("https://api.betabotz.eu.org/api/download/mediafire?url=%s&apikey=%s", url.QueryEscape(link), ctx.Config.APIKey)
 	resp, err := http.Get(apiURL)
 	if err != nil {
 		edit := tgbotapi.NewEditMessageText(ctx.Message.Chat.ID, sent.MessageID, "❌ Failed to get file")
@@ -345,6 +355,7 @@ func (p *MediaFirePlugin) Execute(ctx *plugins.Context) error {
 	if !apiResponse.Status {
 		edit := tgbotapi.NewEditMessageText(ctx.Message.Chat.ID, sent.MessageID, "❌ Failed to get file")
 		ctx.API.Send(edit)
+		ctx.NoCharge()
 		return nil
 	}
 
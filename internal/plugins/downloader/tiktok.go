@@ -28,6 +28,7 @@ func (p *TikTokPlugin) Execute(ctx *plugins.Context) error {
 	if len(ctx.Args) == 0 {
 		msg := tgbotapi.NewMessage(ctx.Message.Chat.ID, "Masukkan link TikTok!\n\nContoh:\n/tiktok https://vt.tiktok.com/xxx")
 		ctx.API.Send(msg)
+		ctx.NoCharge()
 		return nil
 	}
 
@@ -80,10 +81,5 @@ func (p *TikTokPlugin) Execute(ctx *plugins.Context) error {
 	video.ParseMode = "Markdown"
 	ctx.API.Send(video)
 
-	if !ctx.User.Premium {
-		ctx.User.Limit--
-		ctx.DB.SaveUser(ctx.User)
-	}
-
 	return nil
 }
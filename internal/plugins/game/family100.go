@@ -10,39 +10,48 @@ import (
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/levouinse/sofinco-bot/internal/plugins"
+	"github.com/levouinse/sofinco-bot/internal/rewards"
+	"github.com/levouinse/sofinco-bot/internal/telegram"
 )
 
+// family100Timeout is how long a round of Family 100 lasts.
+const family100Timeout = 3 * time.Minute
+
+// family100Reward is what a user earns for each answer they find. The round
+// itself is won by whoever found the most answers once all are found.
+var family100Reward = rewards.Reward{Exp: 25, Money: 500}
+
 type Family100Plugin struct {
 	plugins.BasePlugin
-	games map[int64]*Family100Game
 }
 
 type Family100Game struct {
 	Question string
 	Answers  []string
 	Answered []bool
-	Timeout  time.Time
+	// Finders holds who found each answer, in the order they were found.
+	Finders []int64
 }
 
-var Family100Instance *Family100Plugin
-
 func init() {
-	Family100Instance = &Family100Plugin{
-		games: make(map[int64]*Family100Game),
-	}
-	plugins.Register(Family100Instance)
+	p := &Family100Plugin{}
+	plugins.Register(p)
+	Sessions.Register(p)
 }
 
 func (p *Family100Plugin) Commands() []string { return []string{"family100"} }
 func (p *Family100Plugin) Tags() []string     { return []string{"game"} }
 func (p *Family100Plugin) Help() string       { return "Family 100 game" }
 func (p *Family100Plugin) RequireLimit() bool { return false }
+func (p *Family100Plugin) GameName() string   { return "family100" }
+
+func (p *Family100Plugin) NewState() interface{} { return &Family100Game{} }
 
 func (p *Family100Plugin) Execute(ctx *plugins.Context) error {
 	chatID := ctx.Message.Chat.ID
 
-	if _, exists := p.games[chatID]; exists {
-		ctx.API.Send(tgbotapi.NewMessage(chatID, "❌ Masih ada permainan yang belum selesai!"))
+	if Sessions.Active(chatID) {
+		sendBusy(ctx)
 		return nil
 	}
 
@@ -80,9 +89,11 @@ func (p *Family100Plugin) Execute(ctx *plugins.Context) error {
 		Question: apiResponse.Result.Soal,
 		Answers:  apiResponse.Result.Jawaban,
 		Answered: make([]bool, len(apiResponse.Result.Jawaban)),
-		Timeout:  time.Now().Add(3 * time.Minute),
 	}
-	p.games[chatID] = game
+	if _, err := Sessions.Start(ctx.API, chatID, p.GameName(), game, family100Timeout); err != nil {
+		sendBusy(ctx)
+		return nil
+	}
 
 	msg := fmt.Sprintf("🎯 *Family 100*\n\n*Soal:* %s\n\nTerdapat *%d* jawaban\n⏰ Waktu: 3 menit\n💡 Ketik 'nyerah' untuk menyerah",
 		game.Question, len(game.Answers))
@@ -91,47 +102,25 @@ func (p *Family100Plugin) Execute(ctx *plugins.Context) error {
 	reply.ParseMode = "Markdown"
 	ctx.API.Send(reply)
 
-	go func() {
-		time.Sleep(3 * time.Minute)
-		if g, exists := p.games[chatID]; exists {
-			delete(p.games, chatID)
-			allAnswers := ""
-			for i, ans := range g.Answers {
-				allAnswers += fmt.Sprintf("%d. %s\n", i+1, ans)
-			}
-			timeout := tgbotapi.NewMessage(chatID, fmt.Sprintf("⏰ Waktu habis!\n\n*Jawaban yang benar:*\n%s", allAnswers))
-			timeout.ParseMode = "Markdown"
-			ctx.API.Send(timeout)
-		}
-	}()
-
 	return nil
 }
 
-func (p *Family100Plugin) CheckAnswer(chatID int64, userID int64, answer string) (string, bool) {
-	game, exists := p.games[chatID]
-	if !exists {
-		return "", false
-	}
-
-	if time.Now().After(game.Timeout) {
-		delete(p.games, chatID)
-		return "⏰ Waktu habis!", true
-	}
+func (p *Family100Plugin) OnMessage(ctx *plugins.Context, s *Session) bool {
+	game := s.State.(*Family100Game)
+	answer := messageText(ctx)
 
 	if strings.ToLower(answer) == "nyerah" {
-		allAnswers := ""
-		for i, ans := range game.Answers {
-			allAnswers += fmt.Sprintf("%d. %s\n", i+1, ans)
-		}
-		delete(p.games, chatID)
-		return fmt.Sprintf("🏳️ Menyerah!\n\n*Jawaban yang benar:*\n%s", allAnswers), true
+		s.End()
+		sendMarkdown(ctx.API, s.ChatID, fmt.Sprintf("🏳️ Menyerah!\n\n*Jawaban yang benar:*\n%s", listAnswers(game)))
+		return true
 	}
 
 	// Check if answer is correct
 	for i, ans := range game.Answers {
-		if !game.Answered[i] && strings.EqualFold(strings.TrimSpace(answer), strings.TrimSpace(ans)) {
+		if !game.Answered[i] && strings.EqualFold(answer, strings.TrimSpace(ans)) {
 			game.Answered[i] = true
+			game.Finders = append(game.Finders, ctx.Message.From.ID)
+			summary := grantReward(ctx, ctx.Message.From.ID, family100Reward)
 
 			// Count remaining answers
 			remaining := 0
@@ -142,13 +131,48 @@ func (p *Family100Plugin) CheckAnswer(chatID int64, userID int64, answer string)
 			}
 
 			if remaining == 0 {
-				delete(p.games, chatID)
-				return fmt.Sprintf("✅ Benar! *%s*\n\n🎉 Semua jawaban telah ditemukan!", ans), true
+				s.End()
+				recordWin(ctx, topFinder(game))
+				sendMarkdown(ctx.API, s.ChatID, fmt.Sprintf("✅ Benar! *%s*\n%s\n\n🎉 Semua jawaban telah ditemukan!", ans, summary))
+				rewards.Announce(ctx.API, s.ChatID, summary)
+				return true
 			}
 
-			return fmt.Sprintf("✅ Benar! *%s*\n\n💡 Masih ada %d jawaban lagi", ans, remaining), true
+			sendMarkdown(ctx.API, s.ChatID, fmt.Sprintf("✅ Benar! *%s*\n%s\n\n💡 Masih ada %d jawaban lagi", ans, summary, remaining))
+			rewards.Announce(ctx.API, s.ChatID, summary)
+			return true
 		}
 	}
 
-	return "", false
+	return false
+}
+
+func (p *Family100Plugin) OnTimeout(api telegram.Sender, s *Session) {
+	sendMarkdown(api, s.ChatID, fmt.Sprintf("⏰ Waktu habis!\n\n*Jawaban yang benar:*\n%s", listAnswers(s.State.(*Family100Game))))
+}
+
+func (p *Family100Plugin) Reveal(s *Session) string {
+	return strings.Join(s.State.(*Family100Game).Answers, ", ")
+}
+
+// topFinder returns who found the most answers. A tie goes to whoever
+// reached that count first.
+func topFinder(game *Family100Game) int64 {
+	var top int64
+	found := make(map[int64]int)
+	for _, id := range game.Finders {
+		found[id]++
+		if found[id] > found[top] {
+			top = id
+		}
+	}
+	return top
+}
+
+func listAnswers(game *Family100Game) string {
+	allAnswers := ""
+	for i, ans := range game.Answers {
+		allAnswers += fmt.Sprintf("%d. %s\n", i+1, ans)
+	}
+	return allAnswers
 }
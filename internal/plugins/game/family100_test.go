@@ -0,0 +1,23 @@
+package game
+
+import "testing"
+
+func TestTopFinder(t *testing.T) {
+	tests := []struct {
+		name    string
+		finders []int64
+		want    int64
+	}{
+		{"single finder", []int64{1, 1, 1}, 1},
+		{"most answers", []int64{1, 2, 2, 3}, 2},
+		{"tie goes to first", []int64{1, 2, 2, 1}, 2},
+		{"late majority", []int64{1, 2, 3, 3}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := topFinder(&Family100Game{Finders: tt.finders}); got != tt.want {
+				t.Errorf("topFinder(%v) = %d, want %d", tt.finders, got, tt.want)
+			}
+		})
+	}
+}
@@ -11,11 +11,12 @@ import (
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/levouinse/sofinco-bot/internal/plugins"
+	"github.com/levouinse/sofinco-bot/internal/rewards"
+	"github.com/levouinse/sofinco-bot/internal/telegram"
 )
 
 type MathPlugin struct {
 	plugins.BasePlugin
-	sessions map[int64]*MathSession
 }
 
 type MathSession struct {
@@ -23,11 +24,8 @@ type MathSession struct {
 	Answer   int
 	Mode     string
 	Bonus    int
-	Timeout  time.Time
 }
 
-var MathInstance *MathPlugin
-
 var modes = map[string]struct {
 	Bonus int
 	Time  int
@@ -45,16 +43,18 @@ var modes = map[string]struct {
 }
 
 func init() {
-	MathInstance = &MathPlugin{
-		sessions: make(map[int64]*MathSession),
-	}
-	plugins.Register(MathInstance)
+	p := &MathPlugin{}
+	plugins.Register(p)
+	Sessions.Register(p)
 }
 
 func (p *MathPlugin) Commands() []string { return []string{"math"} }
 func (p *MathPlugin) Tags() []string     { return []string{"game"} }
 func (p *MathPlugin) Help() string       { return "Math quiz game" }
 func (p *MathPlugin) RequireLimit() bool { return false }
+func (p *MathPlugin) GameName() string   { return "math" }
+
+func (p *MathPlugin) NewState() interface{} { return &MathSession{} }
 
 func (p *MathPlugin) Execute(ctx *plugins.Context) error {
 	if len(ctx.Args) == 0 {
@@ -75,7 +75,7 @@ func (p *MathPlugin) Execute(ctx *plugins.Context) error {
 		return nil
 	}
 
-	if _, exists := p.sessions[ctx.Message.Chat.ID]; exists {
+	if Sessions.Active(ctx.Message.Chat.ID) {
 		ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, "Masih ada soal yang belum terjawab!"))
 		return nil
 	}
@@ -116,60 +116,59 @@ func (p *MathPlugin) Execute(ctx *plugins.Context) error {
 	answer := 0
 	fmt.Sscanf(apiResponse.Result.Jawaban, "%d", &answer)
 
-	p.sessions[ctx.Message.Chat.ID] = &MathSession{
+	session := &MathSession{
 		Question: apiResponse.Result.Soal,
 		Answer:   answer,
 		Mode:     mode,
 		Bonus:    modeData.Bonus,
-		Timeout:  time.Now().Add(time.Duration(modeData.Time) * time.Millisecond),
+	}
+	timeout := time.Duration(modeData.Time) * time.Second
+	if _, err := Sessions.Start(ctx.API, ctx.Message.Chat.ID, p.GameName(), session, timeout); err != nil {
+		ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, "Masih ada soal yang belum terjawab!"))
+		return nil
 	}
 
-	msg := fmt.Sprintf("🧮 *Math Quiz - %s*\n\n%s = ?\n\nBonus: +%d XP\nWaktu: %d detik",
-		strings.ToUpper(mode), apiResponse.Result.Soal, modeData.Bonus, modeData.Time/1000)
-	
+	msg := fmt.Sprintf("🧮 *Math Quiz - %s*\n\n%s = ?\n\nBonus: +%d XP, +%d Money\nWaktu: %d detik",
+		strings.ToUpper(mode), apiResponse.Result.Soal, modeData.Bonus, modeData.Money, modeData.Time)
+
 	reply := tgbotapi.NewMessage(ctx.Message.Chat.ID, msg)
 	reply.ParseMode = "Markdown"
 	ctx.API.Send(reply)
 
-	go func() {
-		time.Sleep(time.Duration(modeData.Time) * time.Millisecond)
-		if session, exists := p.sessions[ctx.Message.Chat.ID]; exists {
-			delete(p.sessions, ctx.Message.Chat.ID)
-			timeout := tgbotapi.NewMessage(ctx.Message.Chat.ID, 
-				fmt.Sprintf("⏰ Waktu habis! Jawaban: %d", session.Answer))
-			ctx.API.Send(timeout)
-		}
-	}()
-
 	return nil
 }
 
-func (p *MathPlugin) CheckAnswer(ctx *plugins.Context, answer string) bool {
-	session, exists := p.sessions[ctx.Message.Chat.ID]
-	if !exists {
-		return false
-	}
-
-	if time.Now().After(session.Timeout) {
-		delete(p.sessions, ctx.Message.Chat.ID)
-		return false
-	}
+func (p *MathPlugin) OnMessage(ctx *plugins.Context, s *Session) bool {
+	session := s.State.(*MathSession)
 
-	userAnswer, err := strconv.Atoi(answer)
+	userAnswer, err := strconv.Atoi(messageText(ctx))
 	if err != nil {
 		return false
 	}
 
 	if userAnswer == session.Answer {
-		delete(p.sessions, ctx.Message.Chat.ID)
-		ctx.User.Exp += session.Bonus
-		ctx.DB.SaveUser(ctx.User)
-		
-		msg := tgbotapi.NewMessage(ctx.Message.Chat.ID, 
-			fmt.Sprintf("✅ Benar! +%d XP", session.Bonus))
+		s.End()
+		recordWin(ctx, ctx.Message.From.ID)
+		summary := grantReward(ctx, ctx.Message.From.ID, rewards.Reward{
+			Exp:   session.Bonus,
+			Money: modes[session.Mode].Money,
+		})
+
+		msg := tgbotapi.NewMessage(s.ChatID,
+			fmt.Sprintf("✅ Benar! %s", summary))
 		ctx.API.Send(msg)
+		rewards.Announce(ctx.API, s.ChatID, summary)
 		return true
 	}
 
 	return false
 }
+
+func (p *MathPlugin) OnTimeout(api telegram.Sender, s *Session) {
+	api.Send(tgbotapi.NewMessage(s.ChatID,
+		fmt.Sprintf("⏰ Waktu habis! Jawaban: %d", s.State.(*MathSession).Answer)))
+}
+
+func (p *MathPlugin) Reveal(s *Session) string {
+	return strconv.Itoa(s.State.(*MathSession).Answer)
+}
@@ -5,8 +5,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
-	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/levouinse/sofinco-bot/internal/plugins"
@@ -15,22 +13,13 @@ import (
 // Asah Otak Plugin
 type AsahOtakPlugin struct {
 	plugins.BasePlugin
-	games map[int64]*AsahOtakGame
+	riddleGame
 }
 
-type AsahOtakGame struct {
-	Question string
-	Answer   string
-	Timeout  time.Time
-}
-
-var AsahOtakInstance *AsahOtakPlugin
-
 func init() {
-	AsahOtakInstance = &AsahOtakPlugin{
-		games: make(map[int64]*AsahOtakGame),
-	}
-	plugins.Register(AsahOtakInstance)
+	p := &AsahOtakPlugin{riddleGame: riddleGame{name: "asahotak"}}
+	plugins.Register(p)
+	Sessions.Register(p)
 }
 
 func (p *AsahOtakPlugin) Commands() []string { return []string{"asahotak"} }
@@ -41,8 +30,8 @@ func (p *AsahOtakPlugin) RequireLimit() bool { return false }
 func (p *AsahOtakPlugin) Execute(ctx *plugins.Context) error {
 	chatID := ctx.Message.Chat.ID
 
-	if _, exists := p.games[chatID]; exists {
-		ctx.API.Send(tgbotapi.NewMessage(chatID, "❌ Masih ada permainan yang belum selesai!"))
+	if Sessions.Active(chatID) {
+		sendBusy(ctx)
 		return nil
 	}
 
@@ -71,74 +60,32 @@ func (p *AsahOtakPlugin) Execute(ctx *plugins.Context) error {
 		return fmt.Errorf("API error: %s", apiResponse.Message)
 	}
 
-	game := &AsahOtakGame{
+	game := &riddle{
 		Question: apiResponse.Result.Soal,
 		Answer:   apiResponse.Result.Jawaban,
-		Timeout:  time.Now().Add(60 * time.Second),
 	}
-	p.games[chatID] = game
+	if !p.start(ctx, game) {
+		return nil
+	}
 
 	msg := fmt.Sprintf("🧠 *Asah Otak*\n\n%s\n\n⏰ Waktu: 60 detik\n💡 Ketik 'nyerah' untuk menyerah", game.Question)
 	reply := tgbotapi.NewMessage(chatID, msg)
 	reply.ParseMode = "Markdown"
 	ctx.API.Send(reply)
 
-	go func() {
-		time.Sleep(60 * time.Second)
-		if g, exists := p.games[chatID]; exists {
-			delete(p.games, chatID)
-			timeout := tgbotapi.NewMessage(chatID, fmt.Sprintf("⏰ Waktu habis!\n\n*Jawaban:* %s", g.Answer))
-			timeout.ParseMode = "Markdown"
-			ctx.API.Send(timeout)
-		}
-	}()
-
 	return nil
 }
 
-func (p *AsahOtakPlugin) CheckAnswer(chatID int64, answer string) (string, bool) {
-	game, exists := p.games[chatID]
-	if !exists {
-		return "", false
-	}
-
-	if time.Now().After(game.Timeout) {
-		delete(p.games, chatID)
-		return "⏰ Waktu habis!", true
-	}
-
-	if strings.ToLower(answer) == "nyerah" {
-		delete(p.games, chatID)
-		return fmt.Sprintf("🏳️ Menyerah!\n\n*Jawaban:* %s", game.Answer), true
-	}
-
-	if strings.EqualFold(strings.TrimSpace(answer), strings.TrimSpace(game.Answer)) {
-		delete(p.games, chatID)
-		return fmt.Sprintf("✅ Benar! Jawabannya adalah *%s*\n\n+50 XP", game.Answer), true
-	}
-
-	return "", false
-}
-
 // Siapakah Aku Plugin
 type SiapakahAkuPlugin struct {
 	plugins.BasePlugin
-	games map[int64]*SiapakahAkuGame
+	riddleGame
 }
 
-type SiapakahAkuGame struct {
-	Question string
-	Answer   string
-	Timeout  time.Time
-}
-
-var SiapakahAkuInstance *SiapakahAkuPlugin
-
 func init() {
-	SiapakahAkuInstance = &SiapakahAkuPlugin{
-		games: make(map[int64]*SiapakahAkuGame),
-	}
-	plugins.Register(SiapakahAkuInstance)
+	p := &SiapakahAkuPlugin{riddleGame: riddleGame{name: "siapakahaku"}}
+	plugins.Register(p)
+	Sessions.Register(p)
 }
 
 func (p *SiapakahAkuPlugin) Commands() []string { return []string{"siapakahaku"} }
@@ -149,8 +96,8 @@ func (p *SiapakahAkuPlugin) RequireLimit() bool { return false }
 func (p *SiapakahAkuPlugin) Execute(ctx *plugins.Context) error {
 	chatID := ctx.Message.Chat.ID
 
-	if _, exists := p.games[chatID]; exists {
-		ctx.API.Send(tgbotapi.NewMessage(chatID, "❌ Masih ada permainan yang belum selesai!"))
+	if Sessions.Active(chatID) {
+		sendBusy(ctx)
 		return nil
 	}
 
@@ -179,74 +126,32 @@ func (p *SiapakahAkuPlugin) Execute(ctx *plugins.Context) error {
 		return fmt.Errorf("API error: %s", apiResponse.Message)
 	}
 
-	game := &SiapakahAkuGame{
+	game := &riddle{
 		Question: apiResponse.Result.Soal,
 		Answer:   apiResponse.Result.Jawaban,
-		Timeout:  time.Now().Add(60 * time.Second),
 	}
-	p.games[chatID] = game
+	if !p.start(ctx, game) {
+		return nil
+	}
 
 	msg := fmt.Sprintf("❓ *Siapakah Aku?*\n\n%s\n\n⏰ Waktu: 60 detik\n💡 Ketik 'nyerah' untuk menyerah", game.Question)
 	reply := tgbotapi.NewMessage(chatID, msg)
 	reply.ParseMode = "Markdown"
 	ctx.API.Send(reply)
 
-	go func() {
-		time.Sleep(60 * time.Second)
-		if g, exists := p.games[chatID]; exists {
-			delete(p.games, chatID)
-			timeout := tgbotapi.NewMessage(chatID, fmt.Sprintf("⏰ Waktu habis!\n\n*Jawaban:* %s", g.Answer))
-			timeout.ParseMode = "Markdown"
-			ctx.API.Send(timeout)
-		}
-	}()
-
 	return nil
 }
 
-func (p *SiapakahAkuPlugin) CheckAnswer(chatID int64, answer string) (string, bool) {
-	game, exists := p.games[chatID]
-	if !exists {
-		return "", false
-	}
-
-	if time.Now().After(game.Timeout) {
-		delete(p.games, chatID)
-		return "⏰ Waktu habis!", true
-	}
-
-	if strings.ToLower(answer) == "nyerah" {
-		delete(p.games, chatID)
-		return fmt.Sprintf("🏳️ Menyerah!\n\n*Jawaban:* %s", game.Answer), true
-	}
-
-	if strings.EqualFold(strings.TrimSpace(answer), strings.TrimSpace(game.Answer)) {
-		delete(p.games, chatID)
-		return fmt.Sprintf("✅ Benar! Jawabannya adalah *%s*\n\n+50 XP", game.Answer), true
-	}
-
-	return "", false
-}
-
 // Tebak Lagu Plugin
 type TebakLaguPlugin struct {
 	plugins.BasePlugin
-	games map[int64]*TebakLaguGame
-}
-
-type TebakLaguGame struct {
-	Audio   string
-	Answer  string
-	Timeout time.Time
+	riddleGame
 }
 
-var TebakLaguInstance *TebakLaguPlugin
-
 func init() {
-	TebakLaguInstance = &TebakLaguPlugin{
-		games: make(map[int64]*TebakLaguGame),
-	}
-	plugins.Register(TebakLaguInstance)
+	p := &TebakLaguPlugin{riddleGame: riddleGame{name: "tebaklagu"}}
+	plugins.Register(p)
+	Sessions.Register(p)
 }
 
 func (p *TebakLaguPlugin) Commands() []string { return []string{"tebaklagu"} }
@@ -257,8 +162,8 @@ func (p *TebakLaguPlugin) RequireLimit() bool { return false }
 func (p *TebakLaguPlugin) Execute(ctx *plugins.Context) error {
 	chatID := ctx.Message.Chat.ID
 
-	if _, exists := p.games[chatID]; exists {
-		ctx.API.Send(tgbotapi.NewMessage(chatID, "❌ Masih ada permainan yang belum selesai!"))
+	if Sessions.Active(chatID) {
+		sendBusy(ctx)
 		return nil
 	}
 
@@ -287,51 +192,18 @@ func (p *TebakLaguPlugin) Execute(ctx *plugins.Context) error {
 		return fmt.Errorf("API error: %s", apiResponse.Message)
 	}
 
-	game := &TebakLaguGame{
-		Audio:   apiResponse.Result.Audio,
-		Answer:  apiResponse.Result.Jawaban,
-		Timeout: time.Now().Add(60 * time.Second),
+	game := &riddle{
+		Audio:  apiResponse.Result.Audio,
+		Answer: apiResponse.Result.Jawaban,
+	}
+	if !p.start(ctx, game) {
+		return nil
 	}
-	p.games[chatID] = game
 
 	audio := tgbotapi.NewAudio(chatID, tgbotapi.FileURL(game.Audio))
 	audio.Caption = "🎵 *Tebak Lagu*\n\nApa judul lagu ini?\n⏰ Waktu: 60 detik\n💡 Ketik 'nyerah' untuk menyerah"
 	audio.ParseMode = "Markdown"
 	ctx.API.Send(audio)
 
-	go func() {
-		time.Sleep(60 * time.Second)
-		if g, exists := p.games[chatID]; exists {
-			delete(p.games, chatID)
-			timeout := tgbotapi.NewMessage(chatID, fmt.Sprintf("⏰ Waktu habis!\n\n*Jawaban:* %s", g.Answer))
-			timeout.ParseMode = "Markdown"
-			ctx.API.Send(timeout)
-		}
-	}()
-
 	return nil
 }
-
-func (p *TebakLaguPlugin) CheckAnswer(chatID int64, answer string) (string, bool) {
-	game, exists := p.games[chatID]
-	if !exists {
-		return "", false
-	}
-
-	if time.Now().After(game.Timeout) {
-		delete(p.games, chatID)
-		return "⏰ Waktu habis!", true
-	}
-
-	if strings.ToLower(answer) == "nyerah" {
-		delete(p.games, chatID)
-		return fmt.Sprintf("🏳️ Menyerah!\n\n*Jawaban:* %s", game.Answer), true
-	}
-
-	if strings.EqualFold(strings.TrimSpace(answer), strings.TrimSpace(game.Answer)) {
-		delete(p.games, chatID)
-		return fmt.Sprintf("✅ Benar! Jawabannya adalah *%s*\n\n+50 XP", game.Answer), true
-	}
-
-	return "", false
-}
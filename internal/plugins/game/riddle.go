@@ -0,0 +1,86 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/levouinse/sofinco-bot/internal/plugins"
+	"github.com/levouinse/sofinco-bot/internal/rewards"
+	"github.com/levouinse/sofinco-bot/internal/telegram"
+)
+
+// riddleTimeout is how long the chat has to guess a riddle.
+const riddleTimeout = 60 * time.Second
+
+// riddleReward is what the user who solves a riddle earns.
+var riddleReward = rewards.Reward{Exp: 50}
+
+// riddle is the state of the games where the chat guesses a single answer,
+// like Tebak Kata or Asah Otak.
+type riddle struct {
+	Question string
+	Image    string
+	Audio    string
+	Answer   string
+}
+
+// riddleGame implements Game for riddles. Riddle plugins embed it.
+type riddleGame struct {
+	name string
+}
+
+func (g riddleGame) GameName() string      { return g.name }
+func (g riddleGame) NewState() interface{} { return &riddle{} }
+
+func (g riddleGame) OnMessage(ctx *plugins.Context, s *Session) bool {
+	state := s.State.(*riddle)
+	text := messageText(ctx)
+
+	if strings.EqualFold(text, "nyerah") {
+		s.End()
+		sendMarkdown(ctx.API, s.ChatID, fmt.Sprintf("🏳️ Menyerah!\n\n*Jawaban:* %s", state.Answer))
+		return true
+	}
+
+	if strings.EqualFold(text, strings.TrimSpace(state.Answer)) {
+		s.End()
+		recordWin(ctx, ctx.Message.From.ID)
+		summary := grantReward(ctx, ctx.Message.From.ID, riddleReward)
+		sendMarkdown(ctx.API, s.ChatID, fmt.Sprintf("✅ Benar! Jawabannya adalah *%s*\n\n%s", state.Answer, summary))
+		rewards.Announce(ctx.API, s.ChatID, summary)
+		return true
+	}
+
+	return false
+}
+
+func (g riddleGame) OnTimeout(api telegram.Sender, s *Session) {
+	sendMarkdown(api, s.ChatID, fmt.Sprintf("⏰ Waktu habis!\n\n*Jawaban:* %s", s.State.(*riddle).Answer))
+}
+
+func (g riddleGame) Reveal(s *Session) string {
+	return s.State.(*riddle).Answer
+}
+
+// start begins a riddle in the chat of ctx. It tells the chat and returns
+// false when another game is still running there.
+func (g riddleGame) start(ctx *plugins.Context, state *riddle) bool {
+	if _, err := Sessions.Start(ctx.API, ctx.Message.Chat.ID, g.name, state, riddleTimeout); err != nil {
+		sendBusy(ctx)
+		return false
+	}
+	return true
+}
+
+// sendBusy tells the chat that a game is still running.
+func sendBusy(ctx *plugins.Context) {
+	ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, "❌ Masih ada permainan yang belum selesai!"))
+}
+
+func sendMarkdown(api telegram.Sender, chatID int64, text string) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	api.Send(msg)
+}
@@ -0,0 +1,425 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/levouinse/sofinco-bot/internal/database"
+	"github.com/levouinse/sofinco-bot/internal/plugins"
+	"github.com/levouinse/sofinco-bot/internal/rewards"
+	"github.com/levouinse/sofinco-bot/internal/telegram"
+)
+
+// ErrBusy is returned by Manager.Start when the chat already has a game
+// running.
+var ErrBusy = errors.New("chat already has an active game")
+
+// Game is implemented by game plugins that keep a session running in a
+// chat. Games register themselves with Sessions from init.
+type Game interface {
+	// GameName identifies the game in sessions.
+	GameName() string
+	// NewState returns a pointer to an empty state of the game, which saved
+	// sessions are decoded into. The state is stored as JSON, so only its
+	// exported fields survive a restart.
+	NewState() interface{}
+	// OnMessage is called with the session locked for every message sent
+	// in a chat where the game is active. It reports whether the message
+	// was meant for the game.
+	OnMessage(ctx *plugins.Context, s *Session) bool
+	// OnTimeout is called with the session locked once its deadline has
+	// passed. The session ends afterwards.
+	OnTimeout(api telegram.Sender, s *Session)
+	// Reveal returns the answer announced when a session is cancelled,
+	// or an empty string if there is nothing to reveal.
+	Reveal(s *Session) string
+}
+
+// Session is a game running in a chat. Its fields may only be used while
+// the session is locked, which is the case inside Game callbacks and
+// Manager.Update.
+type Session struct {
+	Game      string
+	ChatID    int64
+	State     interface{}
+	StartedAt time.Time
+	// Deadline is when the session times out. The zero time means never.
+	Deadline time.Time
+
+	mu      sync.Mutex
+	manager *Manager
+	api     telegram.Sender
+	timer   *time.Timer
+	// gen is bumped whenever the timer is re-armed, so a timer that fires
+	// after being replaced does nothing.
+	gen   int
+	ended bool
+}
+
+// End finishes the session and frees the chat for a new game.
+func (s *Session) End() {
+	if s.ended {
+		return
+	}
+	s.ended = true
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.manager.remove(s)
+	if db := s.manager.store(); db != nil {
+		if err := db.DeleteGameSession(s.ChatID); err != nil {
+			log.Printf("Failed to delete %s session in chat %d: %v", s.Game, s.ChatID, err)
+		}
+	}
+}
+
+// Extend moves the deadline to ttl from now. A ttl of zero removes it.
+func (s *Session) Extend(ttl time.Duration) {
+	if ttl <= 0 {
+		s.arm(time.Time{})
+		return
+	}
+	s.arm(time.Now().Add(ttl))
+}
+
+// save writes the session to the database, if the manager has one.
+func (s *Session) save() error {
+	db := s.manager.store()
+	if db == nil {
+		return nil
+	}
+
+	state, err := json.Marshal(s.State)
+	if err != nil {
+		return err
+	}
+	return db.SaveGameSession(&database.GameSession{
+		ChatID:    s.ChatID,
+		Game:      s.Game,
+		State:     state,
+		StartedAt: s.StartedAt,
+		Deadline:  s.Deadline,
+	})
+}
+
+// persist saves the session and logs a failure. The game goes on either
+// way, it just won't survive a restart.
+func (s *Session) persist() {
+	if err := s.save(); err != nil {
+		log.Printf("Failed to save %s session in chat %d: %v", s.Game, s.ChatID, err)
+	}
+}
+
+func (s *Session) arm(deadline time.Time) {
+	s.gen++
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.Deadline = deadline
+	if deadline.IsZero() {
+		return
+	}
+
+	gen := s.gen
+	s.timer = time.AfterFunc(time.Until(deadline), func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.ended || s.gen != gen {
+			return
+		}
+		s.manager.timeout(s)
+	})
+}
+
+// Manager keeps track of the game running in each chat. Once Restore has
+// been called, every change to a session is saved to the database.
+type Manager struct {
+	mu       sync.Mutex
+	games    map[string]Game
+	sessions map[int64]*Session
+	db       *database.Database
+}
+
+// Sessions is the manager shared by every game.
+var Sessions = NewManager()
+
+func NewManager() *Manager {
+	return &Manager{
+		games:    make(map[string]Game),
+		sessions: make(map[int64]*Session),
+	}
+}
+
+// Register makes a game known to the manager.
+func (m *Manager) Register(g Game) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.games[g.GameName()] = g
+}
+
+// Active reports whether chatID has a game running.
+func (m *Manager) Active(chatID int64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.sessions[chatID]
+	return ok
+}
+
+// Start begins a session of game in chatID that times out after ttl, or
+// never when ttl is zero. api is used to announce the timeout.
+func (m *Manager) Start(api telegram.Sender, chatID int64, game string, state interface{}, ttl time.Duration) (*Session, error) {
+	s := &Session{
+		Game:      game,
+		ChatID:    chatID,
+		State:     state,
+		StartedAt: time.Now(),
+		manager:   m,
+		api:       api,
+	}
+
+	m.mu.Lock()
+	if _, busy := m.sessions[chatID]; busy {
+		m.mu.Unlock()
+		return nil, ErrBusy
+	}
+	m.sessions[chatID] = s
+	m.mu.Unlock()
+
+	s.mu.Lock()
+	s.Extend(ttl)
+	s.persist()
+	s.mu.Unlock()
+	return s, nil
+}
+
+// Update runs fn with the session of chatID locked. It reports false if
+// no game of that name is running there.
+func (m *Manager) Update(chatID int64, game string, fn func(s *Session)) bool {
+	s := m.get(chatID)
+	if s == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended || s.Game != game {
+		return false
+	}
+	fn(s)
+	if !s.ended {
+		s.persist()
+	}
+	return true
+}
+
+// HandleMessage passes a message to the game running in its chat. It
+// reports whether the game took the message.
+func (m *Manager) HandleMessage(ctx *plugins.Context) bool {
+	s := m.get(ctx.Message.Chat.ID)
+	if s == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return false
+	}
+	if !s.Deadline.IsZero() && time.Now().After(s.Deadline) {
+		m.timeout(s)
+		return false
+	}
+
+	g := m.game(s.Game)
+	if g == nil {
+		return false
+	}
+	if !g.OnMessage(ctx, s) {
+		return false
+	}
+	if !s.ended {
+		s.persist()
+	}
+	return true
+}
+
+// Restore resumes the sessions saved in db and saves to it from then on.
+// Sessions whose deadline passed while the bot was down time out right
+// away, so the chat still learns the answer.
+func (m *Manager) Restore(api telegram.Sender, db *database.Database) error {
+	m.mu.Lock()
+	m.db = db
+	m.mu.Unlock()
+
+	saved, err := db.GetGameSessions()
+	if err != nil {
+		return fmt.Errorf("gagal memuat sesi game: %w", err)
+	}
+
+	for _, data := range saved {
+		g := m.game(data.Game)
+		if g == nil {
+			log.Printf("Dropping saved session of unknown game %q in chat %d", data.Game, data.ChatID)
+			db.DeleteGameSession(data.ChatID)
+			continue
+		}
+		state := g.NewState()
+		if err := json.Unmarshal(data.State, state); err != nil {
+			log.Printf("Dropping unreadable %s session in chat %d: %v", data.Game, data.ChatID, err)
+			db.DeleteGameSession(data.ChatID)
+			continue
+		}
+
+		s := &Session{
+			Game:      data.Game,
+			ChatID:    data.ChatID,
+			State:     state,
+			StartedAt: data.StartedAt,
+			manager:   m,
+			api:       api,
+		}
+
+		m.mu.Lock()
+		if _, busy := m.sessions[s.ChatID]; busy {
+			m.mu.Unlock()
+			continue
+		}
+		m.sessions[s.ChatID] = s
+		m.mu.Unlock()
+
+		s.mu.Lock()
+		if !data.Deadline.IsZero() && !time.Now().Before(data.Deadline) {
+			s.Deadline = data.Deadline
+			m.timeout(s)
+		} else {
+			s.arm(data.Deadline)
+		}
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// Shutdown stops every running session. Sessions are saved so Restore can
+// resume them after a restart; those that cannot be saved are cancelled
+// and the chat is told about it.
+func (m *Manager) Shutdown(api telegram.Sender) {
+	m.mu.Lock()
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		sessions = append(sessions, s)
+	}
+	m.mu.Unlock()
+
+	for _, s := range sessions {
+		s.mu.Lock()
+		if !s.ended && m.suspend(s) {
+			s.mu.Unlock()
+			continue
+		}
+		if !s.ended {
+			answer := ""
+			if g := m.game(s.Game); g != nil {
+				answer = g.Reveal(s)
+			}
+			s.End()
+			announceCancelled(api, s.ChatID, answer)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// timeout runs the game's timeout handler and ends the session. The
+// session must be locked.
+func (m *Manager) timeout(s *Session) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Recovered from panic in %s timeout: %v", s.Game, r)
+		}
+		s.End()
+	}()
+
+	if g := m.game(s.Game); g != nil {
+		g.OnTimeout(s.api, s)
+	}
+}
+
+// suspend saves s and drops it from memory without ending it, so the saved
+// copy stays in the database. It reports false when s could not be saved.
+// The session must be locked.
+func (m *Manager) suspend(s *Session) bool {
+	if m.store() == nil {
+		return false
+	}
+	if err := s.save(); err != nil {
+		log.Printf("Failed to save %s session in chat %d: %v", s.Game, s.ChatID, err)
+		return false
+	}
+
+	s.ended = true
+	s.gen++
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	m.remove(s)
+	return true
+}
+
+func (m *Manager) store() *database.Database {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.db
+}
+
+func (m *Manager) get(chatID int64) *Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sessions[chatID]
+}
+
+func (m *Manager) game(name string) Game {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.games[name]
+}
+
+func (m *Manager) remove(s *Session) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sessions[s.ChatID] == s {
+		delete(m.sessions, s.ChatID)
+	}
+}
+
+// messageText returns the text of a message, or its caption.
+func messageText(ctx *plugins.Context) string {
+	if ctx.Message.Text != "" {
+		return strings.TrimSpace(ctx.Message.Text)
+	}
+	return strings.TrimSpace(ctx.Message.Caption)
+}
+
+// grantReward credits a winner. A failure is logged and yields an empty
+// summary, the win itself still stands. Callers announce a level-up with
+// rewards.Announce after their reply.
+func grantReward(ctx *plugins.Context, userID int64, reward rewards.Reward) rewards.Summary {
+	summary, err := rewards.Grant(ctx.DB, ctx.Config, userID, reward)
+	if err != nil {
+		log.Printf("Failed to reward user %d: %v", userID, err)
+	}
+	return summary
+}
+
+// recordWin counts a round won by userID for the leaderboards. Games call
+// it once per finished round, unlike grantReward, which can pay out several
+// times in one round. A failure is logged, the win itself still stands.
+func recordWin(ctx *plugins.Context, userID int64) {
+	if err := ctx.DB.RecordWin(ctx.Message.Chat.ID, userID); err != nil {
+		log.Printf("Failed to record win of user %d: %v", userID, err)
+	}
+}
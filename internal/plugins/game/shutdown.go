@@ -0,0 +1,20 @@
+package game
+
+import (
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/levouinse/sofinco-bot/internal/telegram"
+)
+
+// announceCancelled tells a chat that its running game was stopped because
+// the bot is shutting down. answer is revealed when it is not empty.
+func announceCancelled(api telegram.Sender, chatID int64, answer string) {
+	text := "⚠️ Bot sedang dimatikan, permainan dibatalkan."
+	if answer != "" {
+		text += fmt.Sprintf("\n\n*Jawaban:* %s", answer)
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+	api.Send(msg)
+}
@@ -8,8 +8,13 @@ import (
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/levouinse/sofinco-bot/internal/plugins"
+	"github.com/levouinse/sofinco-bot/internal/rewards"
 )
 
+// suitReward is what a user earns for beating the bot. It is XP only:
+// /suit has no limit or cooldown, so paying money would let anyone farm it.
+var suitReward = rewards.Reward{Exp: 10}
+
 type SuitPlugin struct {
 	plugins.BasePlugin
 }
@@ -57,6 +62,7 @@ func (p *SuitPlugin) Execute(ctx *plugins.Context) error {
 
 	// Determine winner
 	result := ""
+	var summary rewards.Summary
 	emoji := map[string]string{
 		"batu":    "🪨",
 		"gunting": "✂️",
@@ -68,9 +74,8 @@ func (p *SuitPlugin) Execute(ctx *plugins.Context) error {
 	} else if (userChoice == "batu" && botChoice == "gunting") ||
 		(userChoice == "gunting" && botChoice == "kertas") ||
 		(userChoice == "kertas" && botChoice == "batu") {
-		result = "🎉 *Kamu Menang!*\n+1000 Money"
-		ctx.User.Exp += 10
-		ctx.DB.SaveUser(ctx.User)
+		summary = grantReward(ctx, ctx.Message.From.ID, suitReward)
+		result = "🎉 *Kamu Menang!*\n" + summary.String()
 	} else {
 		result = "😔 *Kamu Kalah!*"
 	}
@@ -81,6 +86,7 @@ func (p *SuitPlugin) Execute(ctx *plugins.Context) error {
 	reply := tgbotapi.NewMessage(ctx.Message.Chat.ID, msg)
 	reply.ParseMode = "Markdown"
 	ctx.API.Send(reply)
+	rewards.Announce(ctx.API, ctx.Message.Chat.ID, summary)
 
 	return nil
 }
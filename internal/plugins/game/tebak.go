@@ -5,8 +5,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
-	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/levouinse/sofinco-bot/internal/plugins"
@@ -15,22 +13,13 @@ import (
 // Tebak Anime Plugin
 type TebakAnimePlugin struct {
 	plugins.BasePlugin
-	games map[int64]*TebakAnimeGame
+	riddleGame
 }
 
-type TebakAnimeGame struct {
-	Image   string
-	Answer  string
-	Timeout time.Time
-}
-
-var TebakAnimeInstance *TebakAnimePlugin
-
 func init() {
-	TebakAnimeInstance = &TebakAnimePlugin{
-		games: make(map[int64]*TebakAnimeGame),
-	}
-	plugins.Register(TebakAnimeInstance)
+	p := &TebakAnimePlugin{riddleGame: riddleGame{name: "tebakanime"}}
+	plugins.Register(p)
+	Sessions.Register(p)
 }
 
 func (p *TebakAnimePlugin) Commands() []string { return []string{"tebakanime"} }
@@ -41,8 +30,8 @@ func (p *TebakAnimePlugin) RequireLimit() bool { return false }
 func (p *TebakAnimePlugin) Execute(ctx *plugins.Context) error {
 	chatID := ctx.Message.Chat.ID
 
-	if _, exists := p.games[chatID]; exists {
-		ctx.API.Send(tgbotapi.NewMessage(chatID, "❌ Masih ada permainan yang belum selesai!"))
+	if Sessions.Active(chatID) {
+		sendBusy(ctx)
 		return nil
 	}
 
@@ -58,7 +47,7 @@ func (p *TebakAnimePlugin) Execute(ctx *plugins.Context) error {
 		Status  bool   `json:"status"`
 		Message string `json:"message"`
 		Result  struct {
-			Image  string `json:"image"`
+			Image   string `json:"image"`
 			Jawaban string `json:"jawaban"`
 		} `json:"result"`
 	}
@@ -71,74 +60,32 @@ func (p *TebakAnimePlugin) Execute(ctx *plugins.Context) error {
 		return fmt.Errorf("API error: %s", apiResponse.Message)
 	}
 
-	game := &TebakAnimeGame{
-		Image:   apiResponse.Result.Image,
-		Answer:  apiResponse.Result.Jawaban,
-		Timeout: time.Now().Add(60 * time.Second),
+	game := &riddle{
+		Image:  apiResponse.Result.Image,
+		Answer: apiResponse.Result.Jawaban,
+	}
+	if !p.start(ctx, game) {
+		return nil
 	}
-	p.games[chatID] = game
 
 	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileURL(game.Image))
 	photo.Caption = "🎌 *Tebak Anime*\n\nSiapa nama anime ini?\n⏰ Waktu: 60 detik\n💡 Ketik 'nyerah' untuk menyerah"
 	photo.ParseMode = "Markdown"
 	ctx.API.Send(photo)
 
-	go func() {
-		time.Sleep(60 * time.Second)
-		if g, exists := p.games[chatID]; exists {
-			delete(p.games, chatID)
-			timeout := tgbotapi.NewMessage(chatID, fmt.Sprintf("⏰ Waktu habis!\n\n*Jawaban:* %s", g.Answer))
-			timeout.ParseMode = "Markdown"
-			ctx.API.Send(timeout)
-		}
-	}()
-
 	return nil
 }
 
-func (p *TebakAnimePlugin) CheckAnswer(chatID int64, answer string) (string, bool) {
-	game, exists := p.games[chatID]
-	if !exists {
-		return "", false
-	}
-
-	if time.Now().After(game.Timeout) {
-		delete(p.games, chatID)
-		return "⏰ Waktu habis!", true
-	}
-
-	if strings.ToLower(answer) == "nyerah" {
-		delete(p.games, chatID)
-		return fmt.Sprintf("🏳️ Menyerah!\n\n*Jawaban:* %s", game.Answer), true
-	}
-
-	if strings.EqualFold(strings.TrimSpace(answer), strings.TrimSpace(game.Answer)) {
-		delete(p.games, chatID)
-		return fmt.Sprintf("✅ Benar! Jawabannya adalah *%s*\n\n+50 XP", game.Answer), true
-	}
-
-	return "", false
-}
-
 // Tebak Gambar Plugin
 type TebakGambarPlugin struct {
 	plugins.BasePlugin
-	games map[int64]*TebakGambarGame
+	riddleGame
 }
 
-type TebakGambarGame struct {
-	Image   string
-	Answer  string
-	Timeout time.Time
-}
-
-var TebakGambarInstance *TebakGambarPlugin
-
 func init() {
-	TebakGambarInstance = &TebakGambarPlugin{
-		games: make(map[int64]*TebakGambarGame),
-	}
-	plugins.Register(TebakGambarInstance)
+	p := &TebakGambarPlugin{riddleGame: riddleGame{name: "tebakgambar"}}
+	plugins.Register(p)
+	Sessions.Register(p)
 }
 
 func (p *TebakGambarPlugin) Commands() []string { return []string{"tebakgambar"} }
@@ -149,8 +96,8 @@ func (p *TebakGambarPlugin) RequireLimit() bool { return false }
 func (p *TebakGambarPlugin) Execute(ctx *plugins.Context) error {
 	chatID := ctx.Message.Chat.ID
 
-	if _, exists := p.games[chatID]; exists {
-		ctx.API.Send(tgbotapi.NewMessage(chatID, "❌ Masih ada permainan yang belum selesai!"))
+	if Sessions.Active(chatID) {
+		sendBusy(ctx)
 		return nil
 	}
 
@@ -166,7 +113,7 @@ func (p *TebakGambarPlugin) Execute(ctx *plugins.Context) error {
 		Status  bool   `json:"status"`
 		Message string `json:"message"`
 		Result  struct {
-			Image  string `json:"image"`
+			Image   string `json:"image"`
 			Jawaban string `json:"jawaban"`
 		} `json:"result"`
 	}
@@ -179,74 +126,32 @@ func (p *TebakGambarPlugin) Execute(ctx *plugins.Context) error {
 		return fmt.Errorf("API error: %s", apiResponse.Message)
 	}
 
-	game := &TebakGambarGame{
-		Image:   apiResponse.Result.Image,
-		Answer:  apiResponse.Result.Jawaban,
-		Timeout: time.Now().Add(60 * time.Second),
+	game := &riddle{
+		Image:  apiResponse.Result.Image,
+		Answer: apiResponse.Result.Jawaban,
+	}
+	if !p.start(ctx, game) {
+		return nil
 	}
-	p.games[chatID] = game
 
 	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileURL(game.Image))
 	photo.Caption = "🖼️ *Tebak Gambar*\n\nApa yang ada di gambar ini?\n⏰ Waktu: 60 detik\n💡 Ketik 'nyerah' untuk menyerah"
 	photo.ParseMode = "Markdown"
 	ctx.API.Send(photo)
 
-	go func() {
-		time.Sleep(60 * time.Second)
-		if g, exists := p.games[chatID]; exists {
-			delete(p.games, chatID)
-			timeout := tgbotapi.NewMessage(chatID, fmt.Sprintf("⏰ Waktu habis!\n\n*Jawaban:* %s", g.Answer))
-			timeout.ParseMode = "Markdown"
-			ctx.API.Send(timeout)
-		}
-	}()
-
 	return nil
 }
 
-func (p *TebakGambarPlugin) CheckAnswer(chatID int64, answer string) (string, bool) {
-	game, exists := p.games[chatID]
-	if !exists {
-		return "", false
-	}
-
-	if time.Now().After(game.Timeout) {
-		delete(p.games, chatID)
-		return "⏰ Waktu habis!", true
-	}
-
-	if strings.ToLower(answer) == "nyerah" {
-		delete(p.games, chatID)
-		return fmt.Sprintf("🏳️ Menyerah!\n\n*Jawaban:* %s", game.Answer), true
-	}
-
-	if strings.EqualFold(strings.TrimSpace(answer), strings.TrimSpace(game.Answer)) {
-		delete(p.games, chatID)
-		return fmt.Sprintf("✅ Benar! Jawabannya adalah *%s*\n\n+50 XP", game.Answer), true
-	}
-
-	return "", false
-}
-
 // Tebak Kata Plugin
 type TebakKataPlugin struct {
 	plugins.BasePlugin
-	games map[int64]*TebakKataGame
-}
-
-type TebakKataGame struct {
-	Question string
-	Answer   string
-	Timeout  time.Time
+	riddleGame
 }
 
-var TebakKataInstance *TebakKataPlugin
-
 func init() {
-	TebakKataInstance = &TebakKataPlugin{
-		games: make(map[int64]*TebakKataGame),
-	}
-	plugins.Register(TebakKataInstance)
+	p := &TebakKataPlugin{riddleGame: riddleGame{name: "tebakkata"}}
+	plugins.Register(p)
+	Sessions.Register(p)
 }
 
 func (p *TebakKataPlugin) Commands() []string { return []string{"tebakkata"} }
@@ -257,8 +162,8 @@ func (p *TebakKataPlugin) RequireLimit() bool { return false }
 func (p *TebakKataPlugin) Execute(ctx *plugins.Context) error {
 	chatID := ctx.Message.Chat.ID
 
-	if _, exists := p.games[chatID]; exists {
-		ctx.API.Send(tgbotapi.NewMessage(chatID, "❌ Masih ada permainan yang belum selesai!"))
+	if Sessions.Active(chatID) {
+		sendBusy(ctx)
 		return nil
 	}
 
@@ -287,51 +192,18 @@ func (p *TebakKataPlugin) Execute(ctx *plugins.Context) error {
 		return fmt.Errorf("API error: %s", apiResponse.Message)
 	}
 
-	game := &TebakKataGame{
+	game := &riddle{
 		Question: apiResponse.Result.Soal,
 		Answer:   apiResponse.Result.Jawaban,
-		Timeout:  time.Now().Add(60 * time.Second),
 	}
-	p.games[chatID] = game
+	if !p.start(ctx, game) {
+		return nil
+	}
 
 	msg := fmt.Sprintf("📝 *Tebak Kata*\n\n%s\n\n⏰ Waktu: 60 detik\n💡 Ketik 'nyerah' untuk menyerah", game.Question)
 	reply := tgbotapi.NewMessage(chatID, msg)
 	reply.ParseMode = "Markdown"
 	ctx.API.Send(reply)
 
-	go func() {
-		time.Sleep(60 * time.Second)
-		if g, exists := p.games[chatID]; exists {
-			delete(p.games, chatID)
-			timeout := tgbotapi.NewMessage(chatID, fmt.Sprintf("⏰ Waktu habis!\n\n*Jawaban:* %s", g.Answer))
-			timeout.ParseMode = "Markdown"
-			ctx.API.Send(timeout)
-		}
-	}()
-
 	return nil
 }
-
-func (p *TebakKataPlugin) CheckAnswer(chatID int64, answer string) (string, bool) {
-	game, exists := p.games[chatID]
-	if !exists {
-		return "", false
-	}
-
-	if time.Now().After(game.Timeout) {
-		delete(p.games, chatID)
-		return "⏰ Waktu habis!", true
-	}
-
-	if strings.ToLower(answer) == "nyerah" {
-		delete(p.games, chatID)
-		return fmt.Sprintf("🏳️ Menyerah!\n\n*Jawaban:* %s", game.Answer), true
-	}
-
-	if strings.EqualFold(strings.TrimSpace(answer), strings.TrimSpace(game.Answer)) {
-		delete(p.games, chatID)
-		return fmt.Sprintf("✅ Benar! Jawabannya adalah *%s*\n\n+50 XP", game.Answer), true
-	}
-
-	return "", false
-}
@@ -7,11 +7,19 @@ import (
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/levouinse/sofinco-bot/internal/plugins"
+	"github.com/levouinse/sofinco-bot/internal/rewards"
+	"github.com/levouinse/sofinco-bot/internal/telegram"
 )
 
+// tictactoeTimeout is how long a game waits for an opponent or for the
+// next move before it is called off.
+const tictactoeTimeout = 5 * time.Minute
+
+// tictactoeReward is what the winner of a game earns.
+var tictactoeReward = rewards.Reward{Exp: 100, Money: 1000}
+
 type TicTacToePlugin struct {
 	plugins.BasePlugin
-	games map[int64]*TicTacToeGame
 }
 
 type TicTacToeGame struct {
@@ -22,82 +30,73 @@ type TicTacToeGame struct {
 	CreatedAt time.Time
 }
 
-var TicTacToeInstance *TicTacToePlugin
-
 func init() {
-	TicTacToeInstance = &TicTacToePlugin{
-		games: make(map[int64]*TicTacToeGame),
-	}
-	plugins.Register(TicTacToeInstance)
+	p := &TicTacToePlugin{}
+	plugins.Register(p)
+	Sessions.Register(p)
 }
 
 func (p *TicTacToePlugin) Commands() []string { return []string{"tictactoe", "ttt"} }
 func (p *TicTacToePlugin) Tags() []string     { return []string{"game"} }
 func (p *TicTacToePlugin) Help() string       { return "Play Tic Tac Toe game" }
 func (p *TicTacToePlugin) RequireLimit() bool { return false }
+func (p *TicTacToePlugin) GameName() string   { return "tictactoe" }
+
+func (p *TicTacToePlugin) NewState() interface{} { return &TicTacToeGame{} }
 
 func (p *TicTacToePlugin) Execute(ctx *plugins.Context) error {
 	chatID := ctx.Message.Chat.ID
 	userID := ctx.Message.From.ID
 
-	// Check if user already in a game
-	for _, game := range p.games {
+	// Join the game waiting in this chat, if there is one
+	var reply tgbotapi.MessageConfig
+	handled := Sessions.Update(chatID, p.GameName(), func(s *Session) {
+		game := s.State.(*TicTacToeGame)
 		if game.PlayerX == userID || game.PlayerO == userID {
-			ctx.API.Send(tgbotapi.NewMessage(chatID, "❌ Kamu masih dalam permainan!"))
-			return nil
+			reply = tgbotapi.NewMessage(chatID, "❌ Kamu masih dalam permainan!")
+			return
 		}
-	}
-
-	// Check if there's a waiting game
-	var waitingGame *TicTacToeGame
-	for id, game := range p.games {
-		if game.PlayerO == 0 {
-			waitingGame = game
-			chatID = id
-			break
+		if game.PlayerO != 0 {
+			reply = tgbotapi.NewMessage(chatID, "❌ Masih ada permainan yang belum selesai!")
+			return
 		}
-	}
 
-	if waitingGame != nil {
-		// Join existing game
-		waitingGame.PlayerO = userID
-		waitingGame.Turn = "X"
+		game.PlayerO = userID
+		game.Turn = "X"
+		s.Extend(tictactoeTimeout)
 
-		board := p.renderBoard(waitingGame)
+		board := p.renderBoard(game)
 		msg := fmt.Sprintf("🎮 *Tic Tac Toe*\n\n%s\n\nGiliran: <a href=\"tg://user?id=%d\">Player X</a>\nKetik angka 1-9 untuk bermain\nKetik 'nyerah' untuk menyerah",
-			board, waitingGame.PlayerX)
+			board, game.PlayerX)
 
-		reply := tgbotapi.NewMessage(chatID, msg)
+		reply = tgbotapi.NewMessage(chatID, msg)
 		reply.ParseMode = "HTML"
+	})
+	if handled {
 		ctx.API.Send(reply)
-	} else {
-		// Create new game
-		game := &TicTacToeGame{
-			Board:     [9]string{"1", "2", "3", "4", "5", "6", "7", "8", "9"},
-			PlayerX:   userID,
-			PlayerO:   0,
-			Turn:      "X",
-			CreatedAt: time.Now(),
-		}
-		p.games[chatID] = game
+		return nil
+	}
 
-		board := p.renderBoard(game)
-		msg := fmt.Sprintf("🎮 *Tic Tac Toe*\n\n%s\n\n⏳ Menunggu pemain lain...\nKetik /tictactoe untuk bergabung",
-			board)
+	// Create new game
+	game := &TicTacToeGame{
+		Board:     [9]string{"1", "2", "3", "4", "5", "6", "7", "8", "9"},
+		PlayerX:   userID,
+		PlayerO:   0,
+		Turn:      "X",
+		CreatedAt: time.Now(),
+	}
+	if _, err := Sessions.Start(ctx.API, chatID, p.GameName(), game, tictactoeTimeout); err != nil {
+		sendBusy(ctx)
+		return nil
+	}
 
-		reply := tgbotapi.NewMessage(chatID, msg)
-		reply.ParseMode = "Markdown"
-		ctx.API.Send(reply)
+	board := p.renderBoard(game)
+	msg := fmt.Sprintf("🎮 *Tic Tac Toe*\n\n%s\n\n⏳ Menunggu pemain lain...\nKetik /tictactoe untuk bergabung",
+		board)
 
-		// Auto-delete game after 5 minutes if no one joins
-		go func() {
-			time.Sleep(5 * time.Minute)
-			if g, exists := p.games[chatID]; exists && g.PlayerO == 0 {
-				delete(p.games, chatID)
-				ctx.API.Send(tgbotapi.NewMessage(chatID, "⏰ Game dibatalkan karena tidak ada pemain yang bergabung"))
-			}
-		}()
-	}
+	waiting := tgbotapi.NewMessage(chatID, msg)
+	waiting.ParseMode = "Markdown"
+	ctx.API.Send(waiting)
 
 	return nil
 }
@@ -125,10 +124,42 @@ func (p *TicTacToePlugin) cellEmoji(v string) string {
 	return v
 }
 
-func (p *TicTacToePlugin) HandleMove(chatID int64, userID int64, move string) (string, bool) {
-	game, exists := p.games[chatID]
-	if !exists {
-		return "", false
+func (p *TicTacToePlugin) OnMessage(ctx *plugins.Context, s *Session) bool {
+	response, winner, handled := p.handleMove(s, ctx.Message.From.ID, messageText(ctx))
+	if handled {
+		var summary rewards.Summary
+		if winner != 0 {
+			recordWin(ctx, winner)
+			summary = grantReward(ctx, winner, tictactoeReward)
+			response += "\n" + summary.String()
+		}
+		reply := tgbotapi.NewMessage(s.ChatID, response)
+		reply.ParseMode = "HTML"
+		ctx.API.Send(reply)
+		rewards.Announce(ctx.API, s.ChatID, summary)
+	}
+	return handled
+}
+
+func (p *TicTacToePlugin) OnTimeout(api telegram.Sender, s *Session) {
+	text := "⏰ Game dibatalkan karena tidak ada gerakan"
+	if s.State.(*TicTacToeGame).PlayerO == 0 {
+		text = "⏰ Game dibatalkan karena tidak ada pemain yang bergabung"
+	}
+	api.Send(tgbotapi.NewMessage(s.ChatID, text))
+}
+
+func (p *TicTacToePlugin) Reveal(s *Session) string { return "" }
+
+// handleMove plays move for userID. winner is the user who won the game
+// with this move, or zero.
+func (p *TicTacToePlugin) handleMove(s *Session, userID int64, move string) (response string, winner int64, handled bool) {
+	game := s.State.(*TicTacToeGame)
+
+	// Ignore messages while waiting for an opponent and from people who
+	// are not playing
+	if game.PlayerO == 0 || (userID != game.PlayerX && userID != game.PlayerO) {
+		return "", 0, false
 	}
 
 	// Check if it's player's turn
@@ -138,7 +169,7 @@ func (p *TicTacToePlugin) HandleMove(chatID int64, userID int64, move string) (s
 	}
 
 	if userID != currentPlayer {
-		return "❌ Bukan giliran kamu!", true
+		return "❌ Bukan giliran kamu!", 0, true
 	}
 
 	// Handle surrender
@@ -147,20 +178,20 @@ func (p *TicTacToePlugin) HandleMove(chatID int64, userID int64, move string) (s
 		if userID == game.PlayerO {
 			winner = game.PlayerX
 		}
-		delete(p.games, chatID)
-		return fmt.Sprintf("🏳️ <a href=\"tg://user?id=%d\">Player</a> menyerah!\n🏆 <a href=\"tg://user?id=%d\">Player</a> menang!", userID, winner), true
+		s.End()
+		return fmt.Sprintf("🏳️ <a href=\"tg://user?id=%d\">Player</a> menyerah!\n🏆 <a href=\"tg://user?id=%d\">Player</a> menang!", userID, winner), winner, true
 	}
 
 	// Validate move
 	pos := -1
 	fmt.Sscanf(move, "%d", &pos)
 	if pos < 1 || pos > 9 {
-		return "❌ Pilih angka 1-9!", true
+		return "❌ Pilih angka 1-9!", 0, true
 	}
 
 	pos-- // Convert to 0-indexed
 	if game.Board[pos] == "X" || game.Board[pos] == "O" {
-		return "❌ Posisi sudah terisi!", true
+		return "❌ Posisi sudah terisi!", 0, true
 	}
 
 	// Make move
@@ -169,12 +200,12 @@ func (p *TicTacToePlugin) HandleMove(chatID int64, userID int64, move string) (s
 	// Check winner
 	if winner := p.checkWinner(game); winner != "" {
 		board := p.renderBoard(game)
-		delete(p.games, chatID)
+		s.End()
 		winnerID := game.PlayerX
 		if winner == "O" {
 			winnerID = game.PlayerO
 		}
-		return fmt.Sprintf("🎮 *Tic Tac Toe*\n\n%s\n\n🏆 <a href=\"tg://user?id=%d\">Player %s</a> menang!", board, winnerID, winner), true
+		return fmt.Sprintf("🎮 *Tic Tac Toe*\n\n%s\n\n🏆 <a href=\"tg://user?id=%d\">Player %s</a> menang!", board, winnerID, winner), winnerID, true
 	}
 
 	// Check draw
@@ -187,11 +218,12 @@ func (p *TicTacToePlugin) HandleMove(chatID int64, userID int64, move string) (s
 	}
 	if draw {
 		board := p.renderBoard(game)
-		delete(p.games, chatID)
-		return fmt.Sprintf("🎮 *Tic Tac Toe*\n\n%s\n\n🤝 Seri!", board), true
+		s.End()
+		return fmt.Sprintf("🎮 *Tic Tac Toe*\n\n%s\n\n🤝 Seri!", board), 0, true
 	}
 
 	// Switch turn
+	s.Extend(tictactoeTimeout)
 	if game.Turn == "X" {
 		game.Turn = "O"
 	} else {
@@ -204,7 +236,7 @@ func (p *TicTacToePlugin) HandleMove(chatID int64, userID int64, move string) (s
 		nextPlayer = game.PlayerO
 	}
 
-	return fmt.Sprintf("🎮 *Tic Tac Toe*\n\n%s\n\nGiliran: <a href=\"tg://user?id=%d\">Player %s</a>", board, nextPlayer, game.Turn), true
+	return fmt.Sprintf("🎮 *Tic Tac Toe*\n\n%s\n\nGiliran: <a href=\"tg://user?id=%d\">Player %s</a>", board, nextPlayer, game.Turn), 0, true
 }
 
 func (p *TicTacToePlugin) checkWinner(game *TicTacToeGame) string {
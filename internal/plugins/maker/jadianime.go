@@ -25,19 +25,17 @@ func (p *JadiAnimePlugin) Help() string       { return "Convert photo to anime s
 func (p *JadiAnimePlugin) RequireLimit() bool { return true }
 
 func (p *JadiAnimePlugin) Execute(ctx *plugins.Context) error {
-	if ctx.Message.ReplyToMessage == nil || ctx.Message.ReplyToMessage.Photo == nil {
-		msg := tgbotapi.NewMessage(ctx.Message.Chat.ID, "Reply ke foto yang ingin dijadikan anime!")
+	if !ctx.Media.Is(plugins.MediaPhoto) {
+		msg := tgbotapi.NewMessage(ctx.Message.Chat.ID, "Kirim foto dengan caption /jadianime atau reply ke foto yang ingin dijadikan anime!")
 		ctx.API.Send(msg)
+		ctx.NoCharge()
 		return nil
 	}
 
 	waitMsg := tgbotapi.NewMessage(ctx.Message.Chat.ID, "🎨 Converting to anime...")
 	sent, _ := ctx.API.Send(waitMsg)
 
-	photos := ctx.Message.ReplyToMessage.Photo
-	fileID := photos[len(photos)-1].FileID
-
-	file, err := ctx.API.GetFile(tgbotapi.FileConfig{FileID: fileID})
+	file, err := ctx.API.GetFile(tgbotapi.FileConfig{FileID: ctx.Media.FileID})
 	if err != nil {
 		ctx.API.Send(tgbotapi.NewDeleteMessage(ctx.Message.Chat.ID, sent.MessageID))
 		return fmt.Errorf("gagal mendapatkan file: %w", err)
@@ -72,10 +70,5 @@ func (p *JadiAnimePlugin) Execute(ctx *plugins.Context) error {
 	photo.Caption = "🎨 Anime Style"
 	ctx.API.Send(photo)
 
-	if !ctx.User.Premium {
-		ctx.User.Limit--
-		ctx.DB.SaveUser(ctx.User)
-	}
-
 	return nil
 }
@@ -0,0 +1,77 @@
+package plugins
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Media types reported in Media.Type.
+const (
+	MediaPhoto     = "photo"
+	MediaVideo     = "video"
+	MediaAnimation = "animation"
+	MediaSticker   = "sticker"
+	MediaDocument  = "document"
+	MediaAudio     = "audio"
+	MediaVoice     = "voice"
+)
+
+// Media is a file attached to a message.
+type Media struct {
+	Type     string
+	FileID   string
+	MimeType string
+	FileSize int
+	// Message is the message the file is attached to, which is either
+	// the command itself or the message it replies to.
+	Message *tgbotapi.Message
+}
+
+// Is reports whether the media is one of the given types.
+func (m *Media) Is(types ...string) bool {
+	if m == nil {
+		return false
+	}
+	for _, t := range types {
+		if m.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// FindMedia returns the media attached to msg, or to the message it
+// replies to when msg has none. Photos resolve to their largest size.
+func FindMedia(msg *tgbotapi.Message) *Media {
+	if msg == nil {
+		return nil
+	}
+	if media := mediaOf(msg); media != nil {
+		return media
+	}
+	return mediaOf(msg.ReplyToMessage)
+}
+
+func mediaOf(msg *tgbotapi.Message) *Media {
+	if msg == nil {
+		return nil
+	}
+
+	switch {
+	case len(msg.Photo) > 0:
+		photo := msg.Photo[len(msg.Photo)-1]
+		return &Media{Type: MediaPhoto, FileID: photo.FileID, MimeType: "image/jpeg", FileSize: photo.FileSize, Message: msg}
+	case msg.Animation != nil:
+		return &Media{Type: MediaAnimation, FileID: msg.Animation.FileID, MimeType: msg.Animation.MimeType, FileSize: msg.Animation.FileSize, Message: msg}
+	case msg.Video != nil:
+		return &Media{Type: MediaVideo, FileID: msg.Video.FileID, MimeType: msg.Video.MimeType, FileSize: msg.Video.FileSize, Message: msg}
+	case msg.Sticker != nil:
+		return &Media{Type: MediaSticker, FileID: msg.Sticker.FileID, FileSize: msg.Sticker.FileSize, Message: msg}
+	case msg.Document != nil:
+		return &Media{Type: MediaDocument, FileID: msg.Document.FileID, MimeType: msg.Document.MimeType, FileSize: msg.Document.FileSize, Message: msg}
+	case msg.Audio != nil:
+		return &Media{Type: MediaAudio, FileID: msg.Audio.FileID, MimeType: msg.Audio.MimeType, FileSize: msg.Audio.FileSize, Message: msg}
+	case msg.Voice != nil:
+		return &Media{Type: MediaVoice, FileID: msg.Voice.FileID, MimeType: msg.Voice.MimeType, FileSize: msg.Voice.FileSize, Message: msg}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package plugins
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestFindMedia(t *testing.T) {
+	reply := &tgbotapi.Message{MessageID: 2, Document: &tgbotapi.Document{FileID: "doc", MimeType: "application/pdf"}}
+
+	tests := []struct {
+		name   string
+		msg    *tgbotapi.Message
+		typ    string
+		fileID string
+	}{
+		{"nil", nil, "", ""},
+		{"none", &tgbotapi.Message{Text: "hi"}, "", ""},
+		{"largest photo", &tgbotapi.Message{Photo: []tgbotapi.PhotoSize{{FileID: "s"}, {FileID: "m"}, {FileID: "l"}}}, MediaPhoto, "l"},
+		{"animation before video", &tgbotapi.Message{Animation: &tgbotapi.Animation{FileID: "gif"}, Video: &tgbotapi.Video{FileID: "mp4"}}, MediaAnimation, "gif"},
+		{"sticker", &tgbotapi.Message{Sticker: &tgbotapi.Sticker{FileID: "st"}}, MediaSticker, "st"},
+		{"voice", &tgbotapi.Message{Voice: &tgbotapi.Voice{FileID: "v"}}, MediaVoice, "v"},
+		{"reply", &tgbotapi.Message{ReplyToMessage: reply}, MediaDocument, "doc"},
+		{"own media wins", &tgbotapi.Message{Audio: &tgbotapi.Audio{FileID: "a"}, ReplyToMessage: reply}, MediaAudio, "a"},
+	}
+
+	for _, tt := range tests {
+		media := FindMedia(tt.msg)
+		if tt.typ == "" {
+			if media != nil {
+				t.Errorf("%s: FindMedia = %+v, want nil", tt.name, media)
+			}
+			continue
+		}
+		if media == nil || !media.Is(tt.typ) || media.FileID != tt.fileID {
+			t.Errorf("%s: FindMedia = %+v, want %s %s", tt.name, media, tt.typ, tt.fileID)
+		}
+	}
+}
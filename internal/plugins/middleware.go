@@ -0,0 +1,210 @@
+package plugins
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/levouinse/sofinco-bot/internal/database"
+	"github.com/levouinse/sofinco-bot/internal/i18n"
+)
+
+// Handler runs a command for a context.
+type Handler func(ctx *Context) error
+
+// Middleware wraps a Handler with extra behaviour.
+type Middleware func(next Handler) Handler
+
+// MiddlewareProvider is implemented by plugins that want their own
+// middleware to run around their Execute.
+type MiddlewareProvider interface {
+	Middlewares() []Middleware
+}
+
+var (
+	middlewareMu sync.RWMutex
+	middlewares  []Middleware
+)
+
+// Use registers middleware that runs around every plugin, after the
+// built-in middleware.
+func Use(mw ...Middleware) {
+	middlewareMu.Lock()
+	defer middlewareMu.Unlock()
+	middlewares = append(middlewares, mw...)
+}
+
+// Chain wraps h so that the first middleware is the outermost one.
+func Chain(h Handler, mw ...Middleware) Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// Pipeline returns the handler that runs plugin: the built-in middleware,
+// then everything registered with Use, then the plugin's own middleware.
+func Pipeline(plugin Plugin) Handler {
+	middlewareMu.RLock()
+	mw := append(DefaultMiddlewares(), middlewares...)
+	middlewareMu.RUnlock()
+
+	if p, ok := plugin.(MiddlewareProvider); ok {
+		mw = append(mw, p.Middlewares()...)
+	}
+
+	return Chain(plugin.Execute, mw...)
+}
+
+// DefaultMiddlewares returns the built-in middleware in the order it runs.
+func DefaultMiddlewares() []Middleware {
+	return []Middleware{
+		Logging(),
+		Timing(),
+		ReplyErrors(),
+		CheckRequirements(),
+		ChargeLimit(),
+		Recover(),
+	}
+}
+
+// Logging logs every command with the user and chat that sent it.
+func Logging() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx *Context) error {
+			log.Printf("Command /%s from %d in %d", ctx.Command, ctx.Message.From.ID, ctx.Message.Chat.ID)
+			return next(ctx)
+		}
+	}
+}
+
+// Timing logs how long a command took.
+func Timing() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx *Context) error {
+			start := time.Now()
+			err := next(ctx)
+			log.Printf("Command /%s finished in %s", ctx.Command, time.Since(start).Round(time.Millisecond))
+			return err
+		}
+	}
+}
+
+// ReplyErrors sends errors returned by the plugin back to the chat and
+// swallows them.
+func ReplyErrors() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx *Context) error {
+			if err := next(ctx); err != nil {
+				ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, fmt.Sprintf("❌ Error: %v", err)))
+			}
+			return nil
+		}
+	}
+}
+
+// Recover turns a panic inside a plugin into an error.
+func Recover() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx *Context) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Panic in /%s: %v\n%s", ctx.Command, r, debug.Stack())
+					err = fmt.Errorf("terjadi kesalahan internal")
+				}
+			}()
+			return next(ctx)
+		}
+	}
+}
+
+// CheckRequirements stops commands whose plugin requires a registered user,
+// premium access, a group chat or a group admin when those are not met.
+func CheckRequirements() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx *Context) error {
+			lang := ctx.Message.From.LanguageCode
+			chat := ctx.Message.Chat
+			inGroup := chat.IsGroup() || chat.IsSuperGroup()
+
+			if ctx.Plugin.RequireRegistered() && !ctx.User.Registered {
+				ctx.API.Send(tgbotapi.NewMessage(chat.ID, i18n.T(lang, "require.registered")))
+				return nil
+			}
+
+			if ctx.Plugin.RequirePremium() && !ctx.User.Premium {
+				ctx.API.Send(tgbotapi.NewMessage(chat.ID, i18n.T(lang, "require.premium")))
+				return nil
+			}
+
+			if (ctx.Plugin.RequireGroup() || ctx.Plugin.RequireAdmin()) && !inGroup {
+				ctx.API.Send(tgbotapi.NewMessage(chat.ID, i18n.T(lang, "require.group")))
+				return nil
+			}
+
+			if ctx.Plugin.RequireAdmin() {
+				isAdmin, err := Admins.IsAdmin(ctx.API, chat.ID, ctx.Message.From.ID)
+				if err != nil {
+					log.Printf("Failed to get admins of %d: %v", chat.ID, err)
+					ctx.API.Send(tgbotapi.NewMessage(chat.ID, i18n.T(lang, "admin.unknown")))
+					return nil
+				}
+				if !isAdmin {
+					ctx.API.Send(tgbotapi.NewMessage(chat.ID, i18n.T(lang, "require.admin")))
+					return nil
+				}
+			}
+
+			return next(ctx)
+		}
+	}
+}
+
+// LowLimit is the remaining limit at or below which ChargeLimit warns the
+// user after a charged command.
+const LowLimit = 3
+
+// ChargeLimit takes the plugin's limit cost from the user. Premium users
+// are charged too; they just get a bigger daily quota. The limit is
+// reserved before the plugin runs and given back if it fails or calls
+// NoCharge, so only successful commands cost anything.
+func ChargeLimit() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx *Context) error {
+			if !ctx.Plugin.RequireLimit() {
+				return next(ctx)
+			}
+
+			lang := ctx.Message.From.LanguageCode
+			cost := ctx.Plugin.LimitCost()
+
+			remaining, err := ctx.DB.ChargeLimit(ctx.User.ID, cost)
+			if err == database.ErrInsufficientLimit {
+				ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, i18n.T(lang, "require.limit")))
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("gagal memotong limit: %w", err)
+			}
+			ctx.User.Limit = remaining
+
+			err = next(ctx)
+			if err != nil || ctx.noCharge {
+				if remaining, refundErr := ctx.DB.RefundLimit(ctx.User.ID, cost); refundErr != nil {
+					log.Printf("Failed to refund limit of %d: %v", ctx.User.ID, refundErr)
+				} else {
+					ctx.User.Limit = remaining
+				}
+				return err
+			}
+
+			if remaining <= LowLimit {
+				ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, i18n.T(lang, "limit.low", remaining)))
+			}
+			return nil
+		}
+	}
+}
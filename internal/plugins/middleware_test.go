@@ -0,0 +1,278 @@
+package plugins
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/levouinse/sofinco-bot/internal/database"
+	"github.com/levouinse/sofinco-bot/internal/i18n"
+	"github.com/levouinse/sofinco-bot/internal/telegram"
+)
+
+type testPlugin struct {
+	BasePlugin
+	execute func(ctx *Context) error
+	own     []Middleware
+	cost    int
+}
+
+func (p *testPlugin) LimitCost() int {
+	if p.cost == 0 {
+		return 1
+	}
+	return p.cost
+}
+
+func (p *testPlugin) Execute(ctx *Context) error {
+	if p.execute == nil {
+		return nil
+	}
+	return p.execute(ctx)
+}
+
+func (p *testPlugin) Middlewares() []Middleware { return p.own }
+
+func testContext(plugin Plugin, chatType string) (*Context, *telegram.Recorder) {
+	rec := telegram.NewRecorder()
+	chatID := int64(100)
+	if chatType != "private" {
+		chatID = -100
+	}
+	return &Context{
+		API: rec,
+		Message: &tgbotapi.Message{
+			From: &tgbotapi.User{ID: 100},
+			Chat: &tgbotapi.Chat{ID: chatID, Type: chatType},
+		},
+		User:    &database.User{ID: 100, Limit: 10},
+		Command: "test",
+		Plugin:  plugin,
+	}, rec
+}
+
+// trace returns a middleware that appends name to *calls before and after
+// the rest of the chain runs.
+func trace(calls *[]string, name string) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx *Context) error {
+			*calls = append(*calls, name)
+			err := next(ctx)
+			*calls = append(*calls, "/"+name)
+			return err
+		}
+	}
+}
+
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	h := Chain(func(ctx *Context) error {
+		calls = append(calls, "handler")
+		return nil
+	}, trace(&calls, "a"), trace(&calls, "b"))
+
+	if err := h(nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a", "b", "handler", "/b", "/a"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestPipelineOrder(t *testing.T) {
+	middlewareMu.Lock()
+	saved := middlewares
+	middlewareMu.Unlock()
+	t.Cleanup(func() {
+		middlewareMu.Lock()
+		middlewares = saved
+		middlewareMu.Unlock()
+	})
+
+	var calls []string
+	Use(trace(&calls, "global"))
+	plugin := &testPlugin{
+		execute: func(ctx *Context) error {
+			calls = append(calls, "execute")
+			return nil
+		},
+		own: []Middleware{trace(&calls, "plugin")},
+	}
+	ctx, _ := testContext(plugin, "private")
+
+	if err := Pipeline(plugin)(ctx); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"global", "plugin", "execute", "/plugin", "/global"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestReplyErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		execute func(ctx *Context) error
+		want    []string
+	}{
+		{"success", func(ctx *Context) error { return nil }, nil},
+		{"error", func(ctx *Context) error { return errors.New("boom") }, []string{"❌ Error: boom"}},
+		{"panic", func(ctx *Context) error { panic("boom") }, []string{"❌ Error: terjadi kesalahan internal"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &testPlugin{execute: tt.execute}
+			ctx, rec := testContext(plugin, "private")
+
+			if err := Pipeline(plugin)(ctx); err != nil {
+				t.Fatalf("pipeline returned %v, want errors swallowed", err)
+			}
+			if got := rec.Texts(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("replies = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckRequirements(t *testing.T) {
+	// Chat -100 has a cached admin list; -200 does not, and the recorder's
+	// getChatAdministrators reply can't be decoded, so its lookup fails.
+	Admins.mu.Lock()
+	Admins.chats[-100] = adminEntry{admins: map[int64]bool{100: true}, expires: time.Now().Add(time.Hour)}
+	Admins.mu.Unlock()
+	t.Cleanup(func() {
+		Admins.Invalidate(-100)
+		Admins.Invalidate(-200)
+	})
+
+	tests := []struct {
+		name       string
+		plugin     BasePlugin
+		premium    bool
+		registered bool
+		chatID     int64
+		userID     int64
+		reply      string
+	}{
+		{"registration required", BasePlugin{requireRegistered: true}, false, false, 100, 100, "require.registered"},
+		{"registration met", BasePlugin{requireRegistered: true}, false, true, 100, 100, ""},
+		{"premium required", BasePlugin{requirePremium: true}, false, false, 100, 100, "require.premium"},
+		{"premium met", BasePlugin{requirePremium: true}, true, false, 100, 100, ""},
+		{"group required", BasePlugin{requireGroup: true}, false, false, 100, 100, "require.group"},
+		{"group met", BasePlugin{requireGroup: true}, false, false, -100, 100, ""},
+		{"admin outside group", BasePlugin{requireAdmin: true}, false, false, 100, 100, "require.group"},
+		{"admin required", BasePlugin{requireAdmin: true}, false, false, -100, 200, "require.admin"},
+		{"admin met", BasePlugin{requireAdmin: true}, false, false, -100, 100, ""},
+		{"admin lookup fails", BasePlugin{requireAdmin: true}, false, false, -200, 100, "admin.unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &testPlugin{BasePlugin: tt.plugin}
+			chatType := "private"
+			if tt.chatID < 0 {
+				chatType = "supergroup"
+			}
+			ctx, rec := testContext(plugin, chatType)
+			ctx.Message.Chat.ID = tt.chatID
+			ctx.Message.From.ID = tt.userID
+			ctx.User.Premium = tt.premium
+			ctx.User.Registered = tt.registered
+
+			ran := false
+			h := Chain(func(ctx *Context) error {
+				ran = true
+				return nil
+			}, CheckRequirements())
+			if err := h(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			if ran != (tt.reply == "") {
+				t.Errorf("handler ran = %v", ran)
+			}
+			var want []string
+			if tt.reply != "" {
+				want = []string{i18n.T("", tt.reply)}
+			}
+			if got := rec.Texts(); !reflect.DeepEqual(got, want) {
+				t.Errorf("replies = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestChargeLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		premium bool
+		limit   int
+		cost    int
+		execute func(ctx *Context) error
+		ran     bool
+		want    int
+		reply   string
+	}{
+		{name: "charged", limit: 10, execute: func(ctx *Context) error { return nil }, ran: true, want: 9},
+		{name: "plugin cost", limit: 10, cost: 3, execute: func(ctx *Context) error { return nil }, ran: true, want: 7},
+		{name: "low warning", limit: 4, execute: func(ctx *Context) error { return nil }, ran: true, want: 3, reply: i18n.T("", "limit.low", 3)},
+		{name: "insufficient", limit: 2, cost: 3, ran: false, want: 2, reply: i18n.T("", "require.limit")},
+		{name: "refund on error", limit: 10, execute: func(ctx *Context) error { return errors.New("boom") }, ran: true, want: 10},
+		{name: "refund on panic", limit: 10, execute: func(ctx *Context) error { panic("boom") }, ran: true, want: 10},
+		{name: "no charge", limit: 10, execute: func(ctx *Context) error { ctx.NoCharge(); return nil }, ran: true, want: 10},
+		{name: "premium charged", premium: true, limit: 1000, execute: func(ctx *Context) error { return nil }, ran: true, want: 999},
+		{name: "premium insufficient", premium: true, limit: 0, ran: false, want: 0, reply: i18n.T("", "require.limit")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			ran := false
+			plugin := &testPlugin{
+				BasePlugin: BasePlugin{requireLimit: true},
+				cost:       tt.cost,
+				execute: func(ctx *Context) error {
+					ran = true
+					return tt.execute(ctx)
+				},
+			}
+			ctx, rec := testContext(plugin, "private")
+			ctx.DB = db
+			ctx.User.Limit = tt.limit
+			ctx.User.Premium = tt.premium
+			if err := db.SaveUser(ctx.User); err != nil {
+				t.Fatal(err)
+			}
+
+			Chain(plugin.Execute, ChargeLimit(), Recover())(ctx)
+
+			if ran != tt.ran {
+				t.Errorf("plugin ran = %v, want %v", ran, tt.ran)
+			}
+			user, err := db.GetUser(ctx.User.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.Limit != tt.want || ctx.User.Limit != tt.want {
+				t.Errorf("limit = %d (context %d), want %d", user.Limit, ctx.User.Limit, tt.want)
+			}
+			var want []string
+			if tt.reply != "" {
+				want = []string{tt.reply}
+			}
+			if got := rec.Texts(); !reflect.DeepEqual(got, want) {
+				t.Errorf("replies = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -66,6 +66,10 @@ func (p *AddPremiumPlugin) Execute(ctx *plugins.Context) error {
 	// Set premium
 	user.Premium = true
 	user.PremiumUntil = time.Now().Add(time.Duration(days) * 24 * time.Hour)
+	user.PremiumReminded = nil
+	if user.Limit < ctx.Config.PremiumLimit {
+		user.Limit = ctx.Config.PremiumLimit
+	}
 	
 	if err := ctx.DB.SaveUser(user); err != nil {
 		return fmt.Errorf("gagal menyimpan user: %w", err)
@@ -75,7 +79,7 @@ func (p *AddPremiumPlugin) Execute(ctx *plugins.Context) error {
 		"👤 User ID: %d\n"+
 		"⏰ Durasi: %d hari\n"+
 		"📅 Expired: %s",
-		userID, days, user.PremiumUntil.Format("2006-01-02 15:04:05"))
+		userID, days, user.PremiumUntil.In(ctx.Config.Location).Format("2006-01-02 15:04:05"))
 
 	ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, msg))
 
@@ -84,11 +88,11 @@ func (p *AddPremiumPlugin) Execute(ctx *plugins.Context) error {
 		fmt.Sprintf("🎉 *Selamat!*\n\n"+
 			"Kamu telah mendapatkan akses Premium selama %d hari!\n\n"+
 			"✨ Benefit:\n"+
-			"• Unlimited limit\n"+
+			"• Limit harian %d\n"+
 			"• Priority support\n"+
 			"• Access to premium features\n\n"+
 			"Expired: %s",
-			days, user.PremiumUntil.Format("2006-01-02")))
+			days, ctx.Config.PremiumLimit, user.PremiumUntil.In(ctx.Config.Location).Format("2006-01-02")))
 	notif.ParseMode = "Markdown"
 	ctx.API.Send(notif)
 
@@ -2,7 +2,6 @@ package owner
 
 import (
 	"fmt"
-	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/levouinse/sofinco-bot/internal/plugins"
@@ -42,7 +41,7 @@ func (p *BroadcastPlugin) Execute(ctx *plugins.Context) error {
 		return nil
 	}
 
-	message := strings.Join(ctx.Args, " ")
+	message := ctx.RawArgs
 	
 	// Get all users
 	users := ctx.DB.GetAllUsers()
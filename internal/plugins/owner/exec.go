@@ -3,7 +3,6 @@ package owner
 import (
 	"fmt"
 	"os/exec"
-	"strings"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -44,13 +43,13 @@ func (p *ExecPlugin) Execute(ctx *plugins.Context) error {
 		return nil
 	}
 
-	command := strings.Join(ctx.Args, " ")
+	command := ctx.RawArgs
 	
 	msg := tgbotapi.NewMessage(ctx.Message.Chat.ID, "⏳ Executing...")
 	sent, _ := ctx.API.Send(msg)
 
 	// Execute command with timeout
-	cmdExec := exec.Command("bash", "-c", command)
+	cmdExec := exec.CommandContext(ctx.Ctx, "bash", "-c", command)
 	
 	done := make(chan error, 1)
 	var output []byte
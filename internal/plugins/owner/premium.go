@@ -0,0 +1,153 @@
+package owner
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/levouinse/sofinco-bot/internal/database"
+	"github.com/levouinse/sofinco-bot/internal/plugins"
+)
+
+type DelPremiumPlugin struct {
+	plugins.BasePlugin
+}
+
+func init() {
+	p := &DelPremiumPlugin{}
+	plugins.Register(p)
+}
+
+func (p *DelPremiumPlugin) Commands() []string { return []string{"delprem", "delpremium"} }
+func (p *DelPremiumPlugin) Tags() []string     { return []string{"owner"} }
+func (p *DelPremiumPlugin) Help() string       { return "Remove premium user (owner only)" }
+func (p *DelPremiumPlugin) RequireLimit() bool { return false }
+
+func (p *DelPremiumPlugin) Execute(ctx *plugins.Context) error {
+	// Check if user is owner
+	isOwner := false
+	for _, ownerID := range ctx.Config.OwnerIDs {
+		if ctx.Message.From.ID == ownerID {
+			isOwner = true
+			break
+		}
+	}
+
+	if !isOwner {
+		ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, "❌ Command ini hanya untuk owner!"))
+		return nil
+	}
+
+	if len(ctx.Args) < 1 {
+		ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID,
+			"Usage: /delprem <user_id>\n\nExample:\n/delprem 123456789"))
+		return nil
+	}
+
+	userID, err := strconv.ParseInt(ctx.Args[0], 10, 64)
+	if err != nil {
+		ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, "❌ Invalid user ID"))
+		return nil
+	}
+
+	wasPremium := false
+	err = ctx.DB.UpdateUser(userID, func(user *database.User) error {
+		wasPremium = user.Premium
+		user.Premium = false
+		user.PremiumUntil = time.Time{}
+		user.PremiumReminded = nil
+		return nil
+	})
+	if err == database.ErrUserNotFound || (err == nil && !wasPremium) {
+		ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, "❌ User tersebut bukan user premium"))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("gagal menyimpan user: %w", err)
+	}
+
+	ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID,
+		fmt.Sprintf("✅ Premium berhasil dihapus!\n\n👤 User ID: %d", userID)))
+
+	// Notify user
+	ctx.API.Send(tgbotapi.NewMessage(userID, "ℹ️ Akses Premium kamu telah dicabut oleh owner."))
+
+	return nil
+}
+
+type ListPremiumPlugin struct {
+	plugins.BasePlugin
+}
+
+func init() {
+	p := &ListPremiumPlugin{}
+	plugins.Register(p)
+}
+
+func (p *ListPremiumPlugin) Commands() []string { return []string{"listprem", "listpremium"} }
+func (p *ListPremiumPlugin) Tags() []string     { return []string{"owner"} }
+func (p *ListPremiumPlugin) Help() string       { return "List premium users (owner only)" }
+func (p *ListPremiumPlugin) RequireLimit() bool { return false }
+
+func (p *ListPremiumPlugin) Execute(ctx *plugins.Context) error {
+	// Check if user is owner
+	isOwner := false
+	for _, ownerID := range ctx.Config.OwnerIDs {
+		if ctx.Message.From.ID == ownerID {
+			isOwner = true
+			break
+		}
+	}
+
+	if !isOwner {
+		ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, "❌ Command ini hanya untuk owner!"))
+		return nil
+	}
+
+	var premiums []*database.User
+	for _, user := range ctx.DB.GetAllUsers() {
+		if user.Premium {
+			premiums = append(premiums, user)
+		}
+	}
+
+	if len(premiums) == 0 {
+		ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, "📭 Belum ada user premium"))
+		return nil
+	}
+
+	// Soonest expiry first, permanent premium last
+	sort.Slice(premiums, func(i, j int) bool {
+		a, b := premiums[i].PremiumUntil, premiums[j].PremiumUntil
+		if a.IsZero() != b.IsZero() {
+			return b.IsZero()
+		}
+		return a.Before(b)
+	})
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("💎 Premium Users (%d)\n\n", len(premiums)))
+	for i, user := range premiums {
+		name := user.FirstName
+		if user.Username != "" {
+			name = "@" + user.Username
+		}
+		if name == "" {
+			name = "-"
+		}
+
+		expiry := "Permanent"
+		if !user.PremiumUntil.IsZero() {
+			days := int(time.Until(user.PremiumUntil).Hours() / 24)
+			expiry = fmt.Sprintf("%s (%d hari lagi)", user.PremiumUntil.In(ctx.Config.Location).Format("2006-01-02 15:04"), days)
+		}
+
+		sb.WriteString(fmt.Sprintf("%d. %s\n   ID: %d\n   Expired: %s\n", i+1, name, user.ID, expiry))
+	}
+
+	ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, sb.String()))
+	return nil
+}
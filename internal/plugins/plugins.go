@@ -1,19 +1,43 @@
 package plugins
 
 import (
+	"context"
+
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/levouinse/sofinco-bot/internal/config"
 	"github.com/levouinse/sofinco-bot/internal/database"
+	"github.com/levouinse/sofinco-bot/internal/scheduler"
+	"github.com/levouinse/sofinco-bot/internal/telegram"
 )
 
 type Context struct {
-	API     *tgbotapi.BotAPI
-	DB      *database.Database
-	Config  *config.Config
-	Message *tgbotapi.Message
-	User    *database.User
-	Args    []string
+	// Ctx is cancelled when the bot starts shutting down.
+	Ctx    context.Context
+	API    telegram.Sender
+	DB     *database.Database
+	Config *config.Config
+	// Scheduler persists jobs; handlers for them are registered with
+	// scheduler.Handle.
+	Scheduler *scheduler.Scheduler
+	Message   *tgbotapi.Message
+	User      *database.User
+	Args      []string
+	// RawArgs is the text after the command name exactly as typed, for
+	// plugins that take free text such as /translate or /ai.
+	RawArgs string
 	Command string
+	Plugin  Plugin
+	// Media is the file sent with the command, or the one in the message
+	// it replies to. It is nil when there is none.
+	Media *Media
+
+	noCharge bool
+}
+
+// NoCharge tells the limit middleware not to charge for this call, for
+// example when the plugin only replied with usage help.
+func (ctx *Context) NoCharge() {
+	ctx.noCharge = true
 }
 
 type Plugin interface {
@@ -22,9 +46,45 @@ type Plugin interface {
 	Help() string
 	Execute(ctx *Context) error
 	RequireLimit() bool
+	LimitCost() int
 	RequirePremium() bool
 	RequireGroup() bool
 	RequireAdmin() bool
+	RequireRegistered() bool
+}
+
+// Shutdowner is implemented by plugins that hold state which has to be
+// cleaned up before the bot exits.
+type Shutdowner interface {
+	Shutdown(api telegram.Sender)
+}
+
+// EditedMessageHandler is implemented by plugins that want to see messages
+// being edited. ctx.Message is the edited message; Args and Command are
+// empty.
+type EditedMessageHandler interface {
+	OnEditedMessage(ctx *Context) error
+}
+
+// ChatMemberContext is passed to the chat member hooks.
+type ChatMemberContext struct {
+	Ctx    context.Context
+	API    telegram.Sender
+	DB     *database.Database
+	Config *config.Config
+	Update *tgbotapi.ChatMemberUpdated
+}
+
+// ChatMemberHandler is implemented by plugins that want to know when a
+// member joins, leaves or changes role in a group the bot administers.
+type ChatMemberHandler interface {
+	OnChatMember(ctx *ChatMemberContext) error
+}
+
+// MyChatMemberHandler is implemented by plugins that want to know when the
+// bot itself is added to, removed from or promoted in a chat.
+type MyChatMemberHandler interface {
+	OnMyChatMember(ctx *ChatMemberContext) error
 }
 
 type BasePlugin struct {
@@ -35,21 +95,37 @@ type BasePlugin struct {
 	requirePremium bool
 	requireGroup   bool
 	requireAdmin   bool
+
+	requireRegistered bool
 }
 
 func (p *BasePlugin) Commands() []string      { return p.commands }
 func (p *BasePlugin) Tags() []string          { return p.tags }
 func (p *BasePlugin) Help() string            { return p.help }
 func (p *BasePlugin) RequireLimit() bool      { return p.requireLimit }
+func (p *BasePlugin) LimitCost() int          { return 1 }
 func (p *BasePlugin) RequirePremium() bool    { return p.requirePremium }
 func (p *BasePlugin) RequireGroup() bool      { return p.requireGroup }
 func (p *BasePlugin) RequireAdmin() bool      { return p.requireAdmin }
+func (p *BasePlugin) RequireRegistered() bool { return p.requireRegistered }
 func (p *BasePlugin) Execute(ctx *Context) error { return nil }
 
 var Registry = make(map[string]Plugin)
 
+// registered lists every plugin once, in registration order.
+var registered []Plugin
+
 func Register(plugin Plugin) {
+	registered = append(registered, plugin)
 	for _, cmd := range plugin.Commands() {
 		Registry[cmd] = plugin
 	}
+	if h, ok := plugin.(CallbackHandler); ok {
+		HandleCallback(h.CallbackPrefix(), h.HandleCallback)
+	}
+}
+
+// All returns every registered plugin once, in registration order.
+func All() []Plugin {
+	return append([]Plugin(nil), registered...)
 }
@@ -0,0 +1,79 @@
+package rpg
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/levouinse/sofinco-bot/internal/plugins"
+	"github.com/levouinse/sofinco-bot/internal/rewards"
+)
+
+// ClaimPlugin claims the reward of one period. Each period is registered
+// as its own plugin so that every claim command gets its own entry in the
+// menu and the published command list.
+type ClaimPlugin struct {
+	plugins.BasePlugin
+	period rewards.Period
+	title  string
+	help   string
+}
+
+func init() {
+	plugins.Register(&ClaimPlugin{period: rewards.Daily, title: "Harian", help: "Claim the daily reward"})
+	plugins.Register(&ClaimPlugin{period: rewards.Weekly, title: "Mingguan", help: "Claim the weekly reward"})
+	plugins.Register(&ClaimPlugin{period: rewards.Monthly, title: "Bulanan", help: "Claim the monthly reward"})
+}
+
+func (p *ClaimPlugin) Commands() []string { return []string{p.period.Name} }
+func (p *ClaimPlugin) Tags() []string     { return []string{"rpg"} }
+func (p *ClaimPlugin) Help() string       { return p.help }
+func (p *ClaimPlugin) RequireLimit() bool { return false }
+
+func (p *ClaimPlugin) Execute(ctx *plugins.Context) error {
+	chatID := ctx.Message.Chat.ID
+	period, title := p.period, p.title
+
+	now := time.Now()
+	result, err := rewards.Claim(ctx.DB, ctx.Config, ctx.User.ID, period, now)
+	if errors.Is(err, rewards.ErrAlreadyClaimed) {
+		ctx.API.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf(
+			"⏳ Kamu sudah klaim hadiah %s!\n\nKlaim lagi dalam %s (%s)\n🔥 Streak: %d",
+			strings.ToLower(title), formatWait(result.Next.Sub(now)),
+			result.Next.In(ctx.Config.Location).Format("2006-01-02 15:04"), result.Streak)))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "🎁 Hadiah %s\n\n", title)
+	fmt.Fprintf(&b, "%s\n", result.Summary)
+	fmt.Fprintf(&b, "🔥 Streak: %d", result.Streak)
+	if result.StreakBonus > 0 {
+		fmt.Fprintf(&b, " (bonus +%d%%)", result.StreakBonus)
+	}
+	fmt.Fprintf(&b, "\n\nKlaim lagi: %s", result.Next.In(ctx.Config.Location).Format("2006-01-02 15:04"))
+	ctx.API.Send(tgbotapi.NewMessage(chatID, b.String()))
+
+	rewards.Announce(ctx.API, chatID, result.Summary)
+	return nil
+}
+
+// formatWait renders d as e.g. "2 hari 3 jam" or "5 jam 12 menit".
+func formatWait(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%d hari %d jam", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%d jam %d menit", hours, minutes)
+	}
+	return fmt.Sprintf("%d menit", minutes)
+}
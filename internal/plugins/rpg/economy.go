@@ -0,0 +1,193 @@
+package rpg
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/levouinse/sofinco-bot/internal/database"
+	"github.com/levouinse/sofinco-bot/internal/plugins"
+)
+
+// recentTransactions is the number of ledger entries shown by /balance.
+const recentTransactions = 5
+
+// ledgerKinds describes ledger entries in /balance.
+var ledgerKinds = map[string]string{
+	database.LedgerReward:   "Hadiah",
+	database.LedgerTransfer: "Transfer",
+	database.LedgerPurchase: "Belanja",
+}
+
+// Balance Plugin
+type BalancePlugin struct {
+	plugins.BasePlugin
+}
+
+func init() {
+	p := &BalancePlugin{}
+	plugins.Register(p)
+}
+
+func (p *BalancePlugin) Commands() []string { return []string{"balance", "bal", "dompet"} }
+func (p *BalancePlugin) Tags() []string     { return []string{"rpg"} }
+func (p *BalancePlugin) Help() string       { return "Check your money and recent transactions" }
+func (p *BalancePlugin) RequireLimit() bool { return false }
+
+func (p *BalancePlugin) Execute(ctx *plugins.Context) error {
+	user, err := ctx.DB.GetUser(ctx.User.ID)
+	if err != nil {
+		return fmt.Errorf("gagal membaca data user: %w", err)
+	}
+
+	premium := "Tidak"
+	if user.Premium {
+		premium = "Permanen"
+		if !user.PremiumUntil.IsZero() {
+			premium = "Sampai " + user.PremiumUntil.In(ctx.Config.Location).Format("2006-01-02 15:04")
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "💰 *Dompet %s*\n\n", escapeMarkdown(displayName(user)))
+	fmt.Fprintf(&b, "├ Money: %d\n", user.Money)
+	fmt.Fprintf(&b, "├ Limit: %d\n", user.Limit)
+	fmt.Fprintf(&b, "└ Premium: %s\n", premium)
+
+	entries, err := ctx.DB.GetLedger(user.ID, recentTransactions)
+	if err != nil {
+		return fmt.Errorf("gagal membaca riwayat transaksi: %w", err)
+	}
+	if len(entries) > 0 {
+		b.WriteString("\n🧾 *Transaksi terakhir*\n")
+		for _, entry := range entries {
+			fmt.Fprintf(&b, "%+d • %s\n", entry.Amount, escapeMarkdown(describeEntry(ctx.DB, entry)))
+		}
+	}
+
+	reply := tgbotapi.NewMessage(ctx.Message.Chat.ID, b.String())
+	reply.ParseMode = "Markdown"
+	ctx.API.Send(reply)
+	return nil
+}
+
+func describeEntry(db *database.Database, entry *database.LedgerEntry) string {
+	text := ledgerKinds[entry.Kind]
+	if text == "" {
+		text = entry.Kind
+	}
+	if entry.Counterparty != 0 {
+		other := fmt.Sprintf("User %d", entry.Counterparty)
+		if user, err := db.GetUser(entry.Counterparty); err == nil && user.ID != 0 {
+			other = displayName(user)
+		}
+		if entry.Amount < 0 {
+			text += " ke " + other
+		} else {
+			text += " dari " + other
+		}
+	}
+	if entry.Note != "" {
+		text += " (" + entry.Note + ")"
+	}
+	return text
+}
+
+// Transfer Plugin
+type TransferPlugin struct {
+	plugins.BasePlugin
+}
+
+func init() {
+	p := &TransferPlugin{}
+	plugins.Register(p)
+}
+
+func (p *TransferPlugin) Commands() []string { return []string{"transfer", "tf"} }
+func (p *TransferPlugin) Tags() []string     { return []string{"rpg"} }
+func (p *TransferPlugin) Help() string       { return "Send money to another user" }
+func (p *TransferPlugin) RequireLimit() bool { return false }
+
+func (p *TransferPlugin) RequireRegistered() bool { return true }
+
+func (p *TransferPlugin) Execute(ctx *plugins.Context) error {
+	chatID := ctx.Message.Chat.ID
+	usage := "Usage: /transfer @user <jumlah>\nAtau balas pesan user dengan /transfer <jumlah>\n\nExample: /transfer @budi 5000"
+
+	target, args, err := findTarget(ctx)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		ctx.API.Send(tgbotapi.NewMessage(chatID, usage))
+		return nil
+	}
+	amount, err := strconv.Atoi(args[0])
+	if err != nil || amount <= 0 {
+		ctx.API.Send(tgbotapi.NewMessage(chatID, "❌ Jumlah harus berupa angka lebih dari 0!"))
+		return nil
+	}
+	if target == nil {
+		ctx.API.Send(tgbotapi.NewMessage(chatID, "❌ User tidak ditemukan! Pastikan dia pernah memakai bot ini."))
+		return nil
+	}
+	if target.ID == ctx.User.ID {
+		ctx.API.Send(tgbotapi.NewMessage(chatID, "❌ Tidak bisa transfer ke diri sendiri!"))
+		return nil
+	}
+
+	err = ctx.DB.Transfer(ctx.User.ID, target.ID, amount, "")
+	if errors.Is(err, database.ErrInsufficientMoney) {
+		ctx.API.Send(tgbotapi.NewMessage(chatID, "❌ Money kamu tidak cukup! Cek dengan /balance"))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("gagal transfer: %w", err)
+	}
+
+	ctx.API.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Berhasil transfer %d Money ke %s", amount, displayName(target))))
+	return nil
+}
+
+// findTarget returns the user a command is aimed at and the arguments
+// left after naming them. The user is the sender of the replied message,
+// or given as @username or user ID in the first argument. The user is nil
+// when the named user is not known to the bot.
+func findTarget(ctx *plugins.Context) (*database.User, []string, error) {
+	if reply := ctx.Message.ReplyToMessage; reply != nil && reply.From != nil && !reply.From.IsBot {
+		user, err := ctx.DB.GetUser(reply.From.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("gagal membaca data user: %w", err)
+		}
+		if user.ID == 0 {
+			return nil, ctx.Args, nil
+		}
+		return user, ctx.Args, nil
+	}
+
+	if len(ctx.Args) == 0 {
+		return nil, nil, nil
+	}
+	name, args := ctx.Args[0], ctx.Args[1:]
+
+	if strings.HasPrefix(name, "@") {
+		user, err := ctx.DB.FindUserByUsername(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("gagal mencari user: %w", err)
+		}
+		return user, args, nil
+	}
+	if id, err := strconv.ParseInt(name, 10, 64); err == nil {
+		user, err := ctx.DB.GetUser(id)
+		if err != nil {
+			return nil, nil, fmt.Errorf("gagal membaca data user: %w", err)
+		}
+		if user.ID == 0 {
+			return nil, args, nil
+		}
+		return user, args, nil
+	}
+	return nil, ctx.Args, nil
+}
@@ -0,0 +1,243 @@
+package rpg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/levouinse/sofinco-bot/internal/database"
+	"github.com/levouinse/sofinco-bot/internal/plugins"
+)
+
+// leaderboardPageSize is the number of users listed on one page.
+const leaderboardPageSize = 10
+
+// leaderboardCallback is the callback prefix of the leaderboard buttons.
+const leaderboardCallback = "lb"
+
+// Leaderboard scopes as used in callback data.
+const (
+	scopeGlobal = "g"
+	scopeGroup  = "c"
+)
+
+// boardTitles are the button labels of the boards.
+var boardTitles = map[database.Board]string{
+	database.BoardExp:   "XP",
+	database.BoardLevel: "Level",
+	database.BoardMoney: "Money",
+	database.BoardWins:  "Menang",
+}
+
+// Leaderboard Plugin
+type LeaderboardPlugin struct {
+	plugins.BasePlugin
+}
+
+func init() {
+	p := &LeaderboardPlugin{}
+	plugins.Register(p)
+}
+
+func (p *LeaderboardPlugin) Commands() []string     { return []string{"leaderboard", "lb", "top"} }
+func (p *LeaderboardPlugin) Tags() []string         { return []string{"rpg"} }
+func (p *LeaderboardPlugin) Help() string           { return "Show the top users by XP, level, money or wins" }
+func (p *LeaderboardPlugin) RequireLimit() bool     { return false }
+func (p *LeaderboardPlugin) CallbackPrefix() string { return leaderboardCallback }
+
+func (p *LeaderboardPlugin) Execute(ctx *plugins.Context) error {
+	chat := ctx.Message.Chat
+	inGroup := chat.IsGroup() || chat.IsSuperGroup()
+	board := database.BoardExp
+	scope := scopeGlobal
+	if inGroup {
+		scope = scopeGroup
+	}
+
+	for _, arg := range ctx.Args {
+		arg = strings.ToLower(arg)
+		switch {
+		case arg == "global":
+			scope = scopeGlobal
+		case arg == "group" || arg == "grup":
+			if !inGroup {
+				ctx.API.Send(tgbotapi.NewMessage(chat.ID, "❌ Leaderboard grup hanya bisa dilihat di grup!"))
+				return nil
+			}
+			scope = scopeGroup
+		case parseBoard(arg) != "":
+			board = parseBoard(arg)
+		default:
+			ctx.API.Send(tgbotapi.NewMessage(chat.ID,
+				"Usage: /leaderboard [xp|level|money|wins] [global|group]\nExample: /leaderboard money global"))
+			return nil
+		}
+	}
+
+	text, keyboard, err := renderLeaderboard(ctx.DB, chat, scope, board, 0, ctx.User.ID)
+	if err != nil {
+		return err
+	}
+	reply := tgbotapi.NewMessage(chat.ID, text)
+	reply.ParseMode = "Markdown"
+	reply.ReplyMarkup = keyboard
+	ctx.API.Send(reply)
+	return nil
+}
+
+func (p *LeaderboardPlugin) HandleCallback(ctx *plugins.CallbackContext) error {
+	// The button of the board being shown does nothing
+	if ctx.Message == nil || len(ctx.Args) < 3 {
+		return ctx.Answer("")
+	}
+
+	scope, board := ctx.Args[0], parseBoard(ctx.Args[1])
+	page, _ := strconv.Atoi(ctx.Args[2])
+	if board == "" || (scope != scopeGlobal && scope != scopeGroup) {
+		return ctx.Answer("")
+	}
+
+	text, keyboard, err := renderLeaderboard(ctx.DB, ctx.Message.Chat, scope, board, page, ctx.User.ID)
+	if err != nil {
+		return err
+	}
+	return ctx.Edit(text, &keyboard)
+}
+
+// parseBoard returns the board named by s, or "" if there is none.
+func parseBoard(s string) database.Board {
+	switch s {
+	case "xp", "exp":
+		return database.BoardExp
+	case "level", "lvl":
+		return database.BoardLevel
+	case "money", "uang":
+		return database.BoardMoney
+	case "wins", "win", "menang":
+		return database.BoardWins
+	}
+	return ""
+}
+
+// renderLeaderboard renders one page of a board, globally or for chat.
+func renderLeaderboard(db *database.Database, chat *tgbotapi.Chat, scope string, board database.Board, page int, viewerID int64) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	chatID := int64(0)
+	where := "🌍 Global"
+	if scope == scopeGroup {
+		chatID = chat.ID
+		where = "👥 " + escapeMarkdown(chat.Title)
+	}
+
+	if page < 0 {
+		page = 0
+	}
+	ranks, total, err := db.Leaderboard(chatID, board, page*leaderboardPageSize, leaderboardPageSize)
+	if err != nil {
+		return "", tgbotapi.InlineKeyboardMarkup{}, fmt.Errorf("gagal membaca leaderboard: %w", err)
+	}
+	pages := (total + leaderboardPageSize - 1) / leaderboardPageSize
+	if page >= pages && pages > 0 {
+		page = pages - 1
+		ranks, total, err = db.Leaderboard(chatID, board, page*leaderboardPageSize, leaderboardPageSize)
+		if err != nil {
+			return "", tgbotapi.InlineKeyboardMarkup{}, fmt.Errorf("gagal membaca leaderboard: %w", err)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "🏆 *Leaderboard %s*\n%s\n\n", boardTitles[board], where)
+	if len(ranks) == 0 {
+		b.WriteString("Belum ada data leaderboard.")
+	}
+	for _, rank := range ranks {
+		fmt.Fprintf(&b, "%s %s — %s\n", medal(rank.Position), escapeMarkdown(displayName(rank.User)), formatScore(board, rank.Score))
+	}
+	if pages > 1 {
+		fmt.Fprintf(&b, "\nPage %d/%d", page+1, pages)
+	}
+	if position, err := db.UserRank(chatID, board, viewerID); err == nil && position > 0 {
+		fmt.Fprintf(&b, "\nPosisi kamu: #%d", position)
+	}
+
+	return b.String(), leaderboardKeyboard(chat, scope, board, page, pages), nil
+}
+
+func leaderboardKeyboard(chat *tgbotapi.Chat, scope string, board database.Board, page, pages int) tgbotapi.InlineKeyboardMarkup {
+	var boards []tgbotapi.InlineKeyboardButton
+	for _, b := range database.Boards {
+		if b == board {
+			boards = append(boards, tgbotapi.NewInlineKeyboardButtonData("• "+boardTitles[b]+" •", leaderboardData("-")))
+			continue
+		}
+		boards = append(boards, tgbotapi.NewInlineKeyboardButtonData(boardTitles[b], leaderboardData(scope, string(b), "0")))
+	}
+	rows := [][]tgbotapi.InlineKeyboardButton{boards}
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("‹ Prev", leaderboardData(scope, string(board), strconv.Itoa(page-1))))
+	}
+	if page < pages-1 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("Next ›", leaderboardData(scope, string(board), strconv.Itoa(page+1))))
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+
+	if chat.IsGroup() || chat.IsSuperGroup() {
+		toggle := tgbotapi.NewInlineKeyboardButtonData("🌍 Global", leaderboardData(scopeGlobal, string(board), "0"))
+		if scope == scopeGlobal {
+			toggle = tgbotapi.NewInlineKeyboardButtonData("👥 Grup", leaderboardData(scopeGroup, string(board), "0"))
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(toggle))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func leaderboardData(args ...string) string {
+	// The arguments are short and fixed, so this cannot exceed the limit
+	data, _ := plugins.CallbackData(leaderboardCallback, args...)
+	return data
+}
+
+func medal(position int) string {
+	switch position {
+	case 1:
+		return "🥇"
+	case 2:
+		return "🥈"
+	case 3:
+		return "🥉"
+	}
+	return fmt.Sprintf("%d.", position)
+}
+
+func formatScore(board database.Board, score int) string {
+	switch board {
+	case database.BoardLevel:
+		return fmt.Sprintf("Level %d", score)
+	case database.BoardMoney:
+		return fmt.Sprintf("%d Money", score)
+	case database.BoardWins:
+		return fmt.Sprintf("%d menang", score)
+	}
+	return fmt.Sprintf("%d XP", score)
+}
+
+// displayName returns how a user is shown in rankings.
+func displayName(user *database.User) string {
+	if user.FirstName != "" {
+		return user.FirstName
+	}
+	if user.Username != "" {
+		return "@" + user.Username
+	}
+	return fmt.Sprintf("User %d", user.ID)
+}
+
+// escapeMarkdown escapes the characters that have a meaning in Telegram's
+// legacy Markdown.
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer("_", "\\_", "*", "\\*", "`", "\\`", "[", "\\[").Replace(s)
+}
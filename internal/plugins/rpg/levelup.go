@@ -0,0 +1,75 @@
+package rpg
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/levouinse/sofinco-bot/internal/plugins"
+	"github.com/levouinse/sofinco-bot/internal/rewards"
+)
+
+// progressBarWidth is the number of blocks in the level progress bar.
+const progressBarWidth = 10
+
+// Level Up Plugin
+type LevelUpPlugin struct {
+	plugins.BasePlugin
+}
+
+func init() {
+	p := &LevelUpPlugin{}
+	plugins.Register(p)
+}
+
+func (p *LevelUpPlugin) Commands() []string { return []string{"levelup", "level", "lvl"} }
+func (p *LevelUpPlugin) Tags() []string     { return []string{"rpg"} }
+func (p *LevelUpPlugin) Help() string       { return "Show your level progress" }
+func (p *LevelUpPlugin) RequireLimit() bool { return false }
+
+func (p *LevelUpPlugin) Execute(ctx *plugins.Context) error {
+	// Granting nothing brings the level in line with the XP, in case it
+	// was earned before levels were tracked or the curve changed
+	summary, err := rewards.Grant(ctx.DB, ctx.Config, ctx.User.ID, rewards.Reward{})
+	if err != nil {
+		return err
+	}
+	user, err := ctx.DB.GetUser(ctx.User.ID)
+	if err != nil {
+		return fmt.Errorf("gagal membaca data user: %w", err)
+	}
+
+	curve := rewards.CurveFor(ctx.Config)
+	from, to := curve.Total(user.Level), curve.Total(user.Level+1)
+	gained, needed := user.Exp-from, to-from
+	if gained < 0 {
+		// The curve was made steeper after this level was reached
+		gained = 0
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "📈 *Level Progress*\n\n")
+	fmt.Fprintf(&b, "Level: *%d*\n", user.Level)
+	fmt.Fprintf(&b, "XP: %d / %d\n", user.Exp, to)
+	fmt.Fprintf(&b, "%s %d%%\n\n", progressBar(gained, needed), gained*100/needed)
+	fmt.Fprintf(&b, "Butuh *%d XP* lagi untuk level %d", to-user.Exp, user.Level+1)
+	if level, reward, ok := rewards.NextMilestone(user.Level); ok {
+		fmt.Fprintf(&b, "\n\n🎁 Hadiah level %d: %s", level, reward)
+	}
+
+	rewards.Announce(ctx.API, ctx.Message.Chat.ID, summary)
+
+	reply := tgbotapi.NewMessage(ctx.Message.Chat.ID, b.String())
+	reply.ParseMode = "Markdown"
+	ctx.API.Send(reply)
+	return nil
+}
+
+// progressBar draws gained out of needed as a bar of blocks.
+func progressBar(gained, needed int) string {
+	filled := gained * progressBarWidth / needed
+	if filled > progressBarWidth {
+		filled = progressBarWidth
+	}
+	return strings.Repeat("▰", filled) + strings.Repeat("▱", progressBarWidth-filled)
+}
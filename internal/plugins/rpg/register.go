@@ -0,0 +1,186 @@
+package rpg
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/levouinse/sofinco-bot/internal/database"
+	"github.com/levouinse/sofinco-bot/internal/plugins"
+)
+
+// Limits of the name and age given to /register.
+const (
+	minNameLength = 3
+	maxNameLength = 30
+	minAge        = 5
+	maxAge        = 100
+)
+
+var (
+	errAlreadyRegistered = errors.New("already registered")
+	errNotRegistered     = errors.New("not registered")
+	errWrongSerial       = errors.New("wrong serial")
+)
+
+// parseRegistration splits "name.age" and validates both parts. The error
+// is meant for the user.
+func parseRegistration(s string) (string, int, error) {
+	i := strings.LastIndex(s, ".")
+	if i < 0 {
+		return "", 0, errors.New("format salah")
+	}
+	name := strings.Join(strings.Fields(s[:i]), " ")
+	age, err := strconv.Atoi(strings.TrimSpace(s[i+1:]))
+	if err != nil {
+		return "", 0, errors.New("umur harus berupa angka")
+	}
+
+	if n := utf8.RuneCountInString(name); n < minNameLength || n > maxNameLength {
+		return "", 0, fmt.Errorf("nama harus %d-%d karakter", minNameLength, maxNameLength)
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != ' ' {
+			return "", 0, errors.New("nama hanya boleh berisi huruf, angka dan spasi")
+		}
+	}
+	if age < minAge || age > maxAge {
+		return "", 0, fmt.Errorf("umur harus %d-%d tahun", minAge, maxAge)
+	}
+	return name, age, nil
+}
+
+// newSerial returns a random serial number for a registration.
+func newSerial() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return strings.ToUpper(hex.EncodeToString(b)), nil
+}
+
+// Register Plugin
+type RegisterPlugin struct {
+	plugins.BasePlugin
+}
+
+func init() {
+	p := &RegisterPlugin{}
+	plugins.Register(p)
+}
+
+func (p *RegisterPlugin) Commands() []string { return []string{"register", "reg", "daftar"} }
+func (p *RegisterPlugin) Tags() []string     { return []string{"rpg"} }
+func (p *RegisterPlugin) Help() string       { return "Register with your name and age" }
+func (p *RegisterPlugin) RequireLimit() bool { return false }
+
+func (p *RegisterPlugin) Execute(ctx *plugins.Context) error {
+	chatID := ctx.Message.Chat.ID
+	if ctx.RawArgs == "" {
+		ctx.API.Send(tgbotapi.NewMessage(chatID, "Usage: /register nama.umur\nExample: /register Budi.17"))
+		return nil
+	}
+	name, age, err := parseRegistration(ctx.RawArgs)
+	if err != nil {
+		ctx.API.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Gagal daftar: %v\n\nUsage: /register nama.umur\nExample: /register Budi.17", err)))
+		return nil
+	}
+	serial, err := newSerial()
+	if err != nil {
+		return fmt.Errorf("gagal membuat serial: %w", err)
+	}
+
+	var user *database.User
+	err = ctx.DB.UpdateUser(ctx.User.ID, func(u *database.User) error {
+		if u.Registered {
+			return errAlreadyRegistered
+		}
+		u.Registered = true
+		u.RegisteredAt = time.Now()
+		u.Name = name
+		u.Age = age
+		u.Serial = serial
+		user = u
+		return nil
+	})
+	if errors.Is(err, errAlreadyRegistered) {
+		ctx.API.Send(tgbotapi.NewMessage(chatID, "❌ Kamu sudah terdaftar!\nMau daftar ulang? /unregister <serial> dulu, serial ada di /profile"))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("gagal mendaftarkan user: %w", err)
+	}
+
+	text := fmt.Sprintf("✅ *Pendaftaran berhasil!*\n\n"+
+		"├ Nama: %s\n"+
+		"└ Umur: %d tahun\n\n",
+		user.Name, user.Age)
+	// Anyone who knows the serial can unregister the user, so it is only
+	// shown in private chats
+	if ctx.Message.Chat.IsPrivate() {
+		text += fmt.Sprintf("Serial: `%s`\nSimpan serial kamu, dipakai untuk /unregister", user.Serial)
+	} else {
+		text += "Serial untuk /unregister bisa dilihat di /profile lewat chat pribadi dengan bot"
+	}
+	reply := tgbotapi.NewMessage(chatID, text)
+	reply.ParseMode = "Markdown"
+	ctx.API.Send(reply)
+	return nil
+}
+
+// Unregister Plugin
+type UnregisterPlugin struct {
+	plugins.BasePlugin
+}
+
+func init() {
+	p := &UnregisterPlugin{}
+	plugins.Register(p)
+}
+
+func (p *UnregisterPlugin) Commands() []string { return []string{"unregister", "unreg"} }
+func (p *UnregisterPlugin) Tags() []string     { return []string{"rpg"} }
+func (p *UnregisterPlugin) Help() string       { return "Remove your registration" }
+func (p *UnregisterPlugin) RequireLimit() bool { return false }
+
+func (p *UnregisterPlugin) Execute(ctx *plugins.Context) error {
+	chatID := ctx.Message.Chat.ID
+	if len(ctx.Args) != 1 {
+		ctx.API.Send(tgbotapi.NewMessage(chatID, "Usage: /unregister <serial>\nSerial kamu ada di /profile (chat pribadi)"))
+		return nil
+	}
+
+	err := ctx.DB.UpdateUser(ctx.User.ID, func(u *database.User) error {
+		if !u.Registered {
+			return errNotRegistered
+		}
+		if !strings.EqualFold(u.Serial, ctx.Args[0]) {
+			return errWrongSerial
+		}
+		u.Registered = false
+		u.Name = ""
+		u.Age = 0
+		u.Serial = ""
+		return nil
+	})
+	switch {
+	case errors.Is(err, errNotRegistered):
+		ctx.API.Send(tgbotapi.NewMessage(chatID, "❌ Kamu belum terdaftar!"))
+		return nil
+	case errors.Is(err, errWrongSerial):
+		ctx.API.Send(tgbotapi.NewMessage(chatID, "❌ Serial salah! Cek serial kamu di /profile (chat pribadi)"))
+		return nil
+	case err != nil:
+		return fmt.Errorf("gagal menghapus pendaftaran: %w", err)
+	}
+
+	ctx.API.Send(tgbotapi.NewMessage(chatID, "✅ Pendaftaran kamu sudah dihapus."))
+	return nil
+}
@@ -0,0 +1,167 @@
+package rpg
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/levouinse/sofinco-bot/internal/config"
+	"github.com/levouinse/sofinco-bot/internal/database"
+	"github.com/levouinse/sofinco-bot/internal/plugins"
+)
+
+// shopCallback is the callback prefix of the shop buttons.
+const shopCallback = "shop"
+
+// errPermanentPremium is returned when buying premium for a user whose
+// premium never expires.
+var errPermanentPremium = errors.New("premium is already permanent")
+
+// shopItem is something money can buy. An item gives either limit or
+// days of premium.
+type shopItem struct {
+	ID          string
+	Name        string
+	Price       int
+	Limit       int
+	PremiumDays int
+}
+
+var shopItems = []shopItem{
+	{ID: "limit10", Name: "10 Limit", Price: 5000, Limit: 10},
+	{ID: "limit50", Name: "50 Limit", Price: 20000, Limit: 50},
+	{ID: "premium1", Name: "Premium 1 Hari", Price: 50000, PremiumDays: 1},
+	{ID: "premium7", Name: "Premium 7 Hari", Price: 300000, PremiumDays: 7},
+}
+
+func findShopItem(id string) (shopItem, bool) {
+	for _, item := range shopItems {
+		if item.ID == strings.ToLower(id) {
+			return item, true
+		}
+	}
+	return shopItem{}, false
+}
+
+// apply gives the item to user. Premium extends a running premium period
+// rather than replacing it.
+func (item shopItem) apply(user *database.User, now time.Time) error {
+	user.Limit += item.Limit
+	if item.PremiumDays == 0 {
+		return nil
+	}
+
+	user.ExpirePremium(now)
+	if user.Premium && user.PremiumUntil.IsZero() {
+		return errPermanentPremium
+	}
+	start := now
+	if user.Premium && user.PremiumUntil.After(now) {
+		start = user.PremiumUntil
+	}
+	user.Premium = true
+	user.PremiumUntil = start.Add(time.Duration(item.PremiumDays) * 24 * time.Hour)
+	user.PremiumReminded = nil
+	return nil
+}
+
+// buy charges userID for item and gives it to them. It returns the reply
+// for the user, errors are only returned when the database fails.
+func buy(db *database.Database, cfg *config.Config, userID int64, item shopItem) (string, error) {
+	var user *database.User
+	err := db.Spend(userID, item.Price, item.Name, func(u *database.User) error {
+		user = u
+		return item.apply(u, time.Now())
+	})
+	switch {
+	case errors.Is(err, database.ErrInsufficientMoney):
+		return fmt.Sprintf("❌ Money kamu tidak cukup! %s harganya %d Money.", item.Name, item.Price), nil
+	case errors.Is(err, errPermanentPremium):
+		return "❌ Premium kamu sudah permanen!", nil
+	case err != nil:
+		return "", fmt.Errorf("gagal membeli %s: %w", item.Name, err)
+	}
+
+	text := fmt.Sprintf("✅ Berhasil membeli %s!\n\nSisa Money: %d", item.Name, user.Money)
+	if item.PremiumDays > 0 {
+		text += "\nPremium sampai: " + user.PremiumUntil.In(cfg.Location).Format("2006-01-02 15:04")
+	} else {
+		text += fmt.Sprintf("\nLimit: %d", user.Limit)
+	}
+	return text, nil
+}
+
+// Shop Plugin
+type ShopPlugin struct {
+	plugins.BasePlugin
+}
+
+func init() {
+	p := &ShopPlugin{}
+	plugins.Register(p)
+}
+
+func (p *ShopPlugin) Commands() []string     { return []string{"shop", "buy"} }
+func (p *ShopPlugin) Tags() []string         { return []string{"rpg"} }
+func (p *ShopPlugin) Help() string           { return "Buy limit or premium with money" }
+func (p *ShopPlugin) RequireLimit() bool     { return false }
+func (p *ShopPlugin) CallbackPrefix() string { return shopCallback }
+
+func (p *ShopPlugin) Execute(ctx *plugins.Context) error {
+	chatID := ctx.Message.Chat.ID
+
+	if len(ctx.Args) > 0 {
+		item, ok := findShopItem(ctx.Args[0])
+		if !ok {
+			ctx.API.Send(tgbotapi.NewMessage(chatID, "❌ Item tidak ditemukan! Lihat daftar item dengan /shop"))
+			return nil
+		}
+		text, err := buy(ctx.DB, ctx.Config, ctx.User.ID, item)
+		if err != nil {
+			return err
+		}
+		ctx.API.Send(tgbotapi.NewMessage(chatID, text))
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteString("🛒 *Shop*\n\n")
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, item := range shopItems {
+		fmt.Fprintf(&b, "• *%s* — %d Money\n  `/shop %s`\n", item.Name, item.Price, item.ID)
+		data, err := plugins.CallbackData(shopCallback, item.ID)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("Beli %s", item.Name), data),
+		))
+	}
+	b.WriteString("\nCek Money kamu dengan /balance")
+
+	reply := tgbotapi.NewMessage(chatID, b.String())
+	reply.ParseMode = "Markdown"
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	ctx.API.Send(reply)
+	return nil
+}
+
+// HandleCallback buys the item of the pressed button for whoever pressed
+// it.
+func (p *ShopPlugin) HandleCallback(ctx *plugins.CallbackContext) error {
+	if len(ctx.Args) == 0 {
+		return nil
+	}
+	item, ok := findShopItem(ctx.Args[0])
+	if !ok {
+		return ctx.Alert("❌ Item tidak ditemukan!")
+	}
+
+	text, err := buy(ctx.DB, ctx.Config, ctx.User.ID, item)
+	if err != nil {
+		return err
+	}
+	return ctx.Alert(text)
+}
@@ -29,6 +29,7 @@ func (p *IGStalkPlugin) Execute(ctx *plugins.Context) error {
 	if len(ctx.Args) == 0 {
 		msg := tgbotapi.NewMessage(ctx.Message.Chat.ID, "Masukkan username Instagram!\n\nContoh:\n/igstalk username")
 		ctx.API.Send(msg)
+		ctx.NoCharge()
 		return nil
 	}
 
@@ -101,10 +102,5 @@ func (p *IGStalkPlugin) Execute(ctx *plugins.Context) error {
 	photo.ParseMode = "Markdown"
 	ctx.API.Send(photo)
 
-	if !ctx.User.Premium {
-		ctx.User.Limit--
-		ctx.DB.SaveUser(ctx.User)
-	}
-
 	return nil
 }
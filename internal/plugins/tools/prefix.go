@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/levouinse/sofinco-bot/internal/command"
+	"github.com/levouinse/sofinco-bot/internal/plugins"
+)
+
+// maxPrefixLen bounds the length of a custom prefix in characters.
+const maxPrefixLen = 3
+
+// Set Prefix Plugin
+type SetPrefixPlugin struct {
+	plugins.BasePlugin
+}
+
+func init() {
+	p := &SetPrefixPlugin{}
+	plugins.Register(p)
+}
+
+func (p *SetPrefixPlugin) Commands() []string { return []string{"setprefix", "prefix"} }
+func (p *SetPrefixPlugin) Tags() []string     { return []string{"tools"} }
+func (p *SetPrefixPlugin) Help() string       { return "Set command prefixes for this group" }
+func (p *SetPrefixPlugin) RequireLimit() bool { return false }
+func (p *SetPrefixPlugin) RequireGroup() bool { return true }
+func (p *SetPrefixPlugin) RequireAdmin() bool { return true }
+
+func (p *SetPrefixPlugin) Execute(ctx *plugins.Context) error {
+	chatID := ctx.Message.Chat.ID
+
+	chat, err := ctx.DB.GetChat(chatID)
+	if err != nil {
+		return fmt.Errorf("gagal membaca data grup: %w", err)
+	}
+
+	if len(ctx.Args) == 0 {
+		current := ctx.Config.Prefixes
+		if len(chat.Prefixes) > 0 {
+			current = command.WithSlash(chat.Prefixes)
+		}
+		ctx.API.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf(
+			"Prefix aktif: %s\n\nUsage: /setprefix <prefix...>\nExample: /setprefix . !\n\n"+
+				"Gunakan /setprefix reset untuk kembali ke default.",
+			strings.Join(current, " "))))
+		return nil
+	}
+
+	// "/" is always kept, so a chat that only gives "/" is left with slash
+	// commands alone rather than the defaults, which only reset restores
+	var prefixes []string
+	if !(len(ctx.Args) == 1 && strings.EqualFold(ctx.Args[0], "reset")) {
+		prefixes = []string{command.Slash}
+		for _, prefix := range ctx.Args {
+			if prefix == command.Slash {
+				continue
+			}
+			if !validPrefix(prefix) {
+				ctx.API.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf(
+					"❌ Prefix %q tidak valid. Gunakan 1-%d simbol, tanpa huruf atau angka.", prefix, maxPrefixLen)))
+				return nil
+			}
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	chat.ID = chatID
+	chat.Type = ctx.Message.Chat.Type
+	chat.Title = ctx.Message.Chat.Title
+	chat.Prefixes = prefixes
+	if err := ctx.DB.SaveChat(chat); err != nil {
+		return fmt.Errorf("gagal menyimpan prefix: %w", err)
+	}
+
+	if len(prefixes) == 0 {
+		ctx.API.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf(
+			"✅ Prefix dikembalikan ke default: %s", strings.Join(ctx.Config.Prefixes, " "))))
+		return nil
+	}
+	ctx.API.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf(
+		"✅ Prefix grup diubah ke: %s", strings.Join(prefixes, " "))))
+	return nil
+}
+
+func validPrefix(prefix string) bool {
+	if prefix == "" || utf8.RuneCountInString(prefix) > maxPrefixLen {
+		return false
+	}
+	for _, r := range prefix {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) || r == '_' || r == '@' {
+			return false
+		}
+	}
+	return true
+}
@@ -24,20 +24,22 @@ func (p *ReminiPlugin) Tags() []string     { return []string{"tools"} }
 func (p *ReminiPlugin) Help() string       { return "Enhance image quality" }
 func (p *ReminiPlugin) RequireLimit() bool { return true }
 
+// LimitCost is higher than usual because every enhance is a slow upscale
+// on the remote API.
+func (p *ReminiPlugin) LimitCost() int { return 2 }
+
 func (p *ReminiPlugin) Execute(ctx *plugins.Context) error {
-	if ctx.Message.ReplyToMessage == nil || ctx.Message.ReplyToMessage.Photo == nil {
-		msg := tgbotapi.NewMessage(ctx.Message.Chat.ID, "Reply ke foto yang ingin di-enhance!")
+	if !ctx.Media.Is(plugins.MediaPhoto) {
+		msg := tgbotapi.NewMessage(ctx.Message.Chat.ID, "Kirim foto dengan caption /remini atau reply ke foto yang ingin di-enhance!")
 		ctx.API.Send(msg)
+		ctx.NoCharge()
 		return nil
 	}
 
 	waitMsg := tgbotapi.NewMessage(ctx.Message.Chat.ID, "⏳ Enhancing image...")
 	sent, _ := ctx.API.Send(waitMsg)
 
-	photos := ctx.Message.ReplyToMessage.Photo
-	fileID := photos[len(photos)-1].FileID
-
-	file, err := ctx.API.GetFile(tgbotapi.FileConfig{FileID: fileID})
+	file, err := ctx.API.GetFile(tgbotapi.FileConfig{FileID: ctx.Media.FileID})
 	if err != nil {
 		ctx.API.Send(tgbotapi.NewDeleteMessage(ctx.Message.Chat.ID, sent.MessageID))
 		return fmt.Errorf("gagal mendapatkan file: %w", err)
@@ -72,10 +74,5 @@ func (p *ReminiPlugin) Execute(ctx *plugins.Context) error {
 	photo.Caption = "✨ Enhanced by Remini"
 	ctx.API.Send(photo)
 
-	if !ctx.User.Premium {
-		ctx.User.Limit--
-		ctx.DB.SaveUser(ctx.User)
-	}
-
 	return nil
 }
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/levouinse/sofinco-bot/internal/command"
 	"github.com/levouinse/sofinco-bot/internal/plugins"
 )
 
@@ -26,20 +28,18 @@ func (p *StickerPlugin) Help() string       { return "Convert image/video to sti
 func (p *StickerPlugin) RequireLimit() bool { return true }
 
 func (p *StickerPlugin) Execute(ctx *plugins.Context) error {
-	if ctx.Message.ReplyToMessage == nil {
-		ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, "Reply to an image or video to convert to sticker!"))
+	if ctx.Media == nil {
+		ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, "Send or reply to an image or video to convert to sticker!"))
+		ctx.NoCharge()
 		return nil
 	}
 
-	var fileID string
-	if ctx.Message.ReplyToMessage.Photo != nil && len(ctx.Message.ReplyToMessage.Photo) > 0 {
-		fileID = ctx.Message.ReplyToMessage.Photo[len(ctx.Message.ReplyToMessage.Photo)-1].FileID
-	} else if ctx.Message.ReplyToMessage.Video != nil {
-		fileID = ctx.Message.ReplyToMessage.Video.FileID
-	} else {
+	if !ctx.Media.Is(plugins.MediaPhoto, plugins.MediaVideo, plugins.MediaAnimation) {
 		ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, "❌ Reply to an image or video!"))
+		ctx.NoCharge()
 		return nil
 	}
+	fileID := ctx.Media.FileID
 
 	msg := tgbotapi.NewMessage(ctx.Message.Chat.ID, "⏳ Converting to sticker...")
 	sent, _ := ctx.API.Send(msg)
@@ -82,15 +82,16 @@ func (p *ToImagePlugin) Help() string       { return "Convert sticker to image"
 func (p *ToImagePlugin) RequireLimit() bool { return true }
 
 func (p *ToImagePlugin) Execute(ctx *plugins.Context) error {
-	if ctx.Message.ReplyToMessage == nil || ctx.Message.ReplyToMessage.Sticker == nil {
+	if !ctx.Media.Is(plugins.MediaSticker) {
 		ctx.API.Send(tgbotapi.NewMessage(ctx.Message.Chat.ID, "Reply to a sticker to convert to image!"))
+		ctx.NoCharge()
 		return nil
 	}
 
 	msg := tgbotapi.NewMessage(ctx.Message.Chat.ID, "⏳ Converting to image...")
 	sent, _ := ctx.API.Send(msg)
 
-	fileID := ctx.Message.ReplyToMessage.Sticker.FileID
+	fileID := ctx.Media.FileID
 	file, err := ctx.API.GetFile(tgbotapi.FileConfig{FileID: fileID})
 	if err != nil {
 		edit := tgbotapi.NewEditMessageText(ctx.Message.Chat.ID, sent.MessageID, "❌ Failed to get file")
@@ -132,8 +133,8 @@ func (p *QRCodePlugin) Execute(ctx *plugins.Context) error {
 		return nil
 	}
 
-	text := ctx.Message.Text[len("/qrcode "):]
-	qrURL := fmt.Sprintf("https://api.qrserver.com/v1/create-qr-code/?size=500x500&data=%s", text)
+	text := ctx.RawArgs
+	qrURL := fmt.Sprintf("https://api.qrserver.com/v1/create-qr-code/?size=500x500&data=%s", url.QueryEscape(text))
 
 	photo := tgbotapi.NewPhoto(ctx.Message.Chat.ID, tgbotapi.FileURL(qrURL))
 	photo.Caption = fmt.Sprintf("📱 QR Code for:\n%s", text)
@@ -164,9 +165,9 @@ func (p *TranslatePlugin) Execute(ctx *plugins.Context) error {
 	}
 
 	lang := ctx.Args[0]
-	text := ctx.Message.Text[len("/translate ")+len(lang)+1:]
+	text := command.After(ctx.RawArgs, 1)
 
-	apiURL := fmt.Sprintf("https://api.betabotz.eu.org/api/tools/translate?text=%s&lang=%s&apikey=%s", text, lang, ctx.Config.APIKey)
+	apiURL := fmt.Sprintf("https://api.betabotz.eu.org/api/tools/translate?text=%s&lang=%s&apikey=%s", url.QueryEscape(text), url.QueryEscape(lang), ctx.Config.APIKey)
 	resp, err := http.Get(apiURL)
 	if err != nil {
 		return fmt.Errorf("gagal translate: %w", err)
@@ -217,8 +218,8 @@ func (p *WikipediaPlugin) Execute(ctx *plugins.Context) error {
 		return nil
 	}
 
-	query := ctx.Message.Text[len("/wikipedia "):]
-	apiURL := fmt.Sprintf("https://api.betabotz.eu.org/api/search/wikipedia?query=%s&apikey=%s", query, ctx.Config.APIKey)
+	query := ctx.RawArgs
+	apiURL := fmt.Sprintf("https://api.betabotz.eu.org/api/search/wikipedia?query=%s&apikey=%s", url.QueryEscape(query), ctx.Config.APIKey)
 	
 	resp, err := http.Get(apiURL)
 	if err != nil {
@@ -279,8 +280,8 @@ func (p *CalculatorPlugin) Execute(ctx *plugins.Context) error {
 		return nil
 	}
 
-	expression := ctx.Message.Text[len("/calc "):]
-	apiURL := fmt.Sprintf("https://api.betabotz.eu.org/api/tools/calculator?q=%s&apikey=%s", expression, ctx.Config.APIKey)
+	expression := ctx.RawArgs
+	apiURL := fmt.Sprintf("https://api.betabotz.eu.org/api/tools/calculator?q=%s&apikey=%s", url.QueryEscape(expression), ctx.Config.APIKey)
 	
 	resp, err := http.Get(apiURL)
 	if err != nil {
@@ -0,0 +1,152 @@
+// Package premium revokes expired premium access and reminds users before
+// it runs out.
+package premium
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/levouinse/sofinco-bot/internal/config"
+	"github.com/levouinse/sofinco-bot/internal/database"
+	"github.com/levouinse/sofinco-bot/internal/scheduler"
+	"github.com/levouinse/sofinco-bot/internal/telegram"
+)
+
+// JobName is the scheduler job that checks premium users every hour.
+const JobName = "premium.check"
+
+// Watcher revokes expired premium and sends renewal reminders.
+type Watcher struct {
+	db   *database.Database
+	api  telegram.Sender
+	days []int
+	loc  *time.Location
+}
+
+func NewWatcher(db *database.Database, api telegram.Sender, cfg *config.Config) *Watcher {
+	days := append([]int(nil), cfg.PremiumReminderDays...)
+	sort.Sort(sort.Reverse(sort.IntSlice(days)))
+	return &Watcher{db: db, api: api, days: days, loc: cfg.Location}
+}
+
+// Schedule registers a job that checks premium users at the start of
+// every hour.
+func (w *Watcher) Schedule(s *scheduler.Scheduler) error {
+	scheduler.Handle(JobName, func(ctx *scheduler.Context) error {
+		w.Check(time.Now())
+		return nil
+	})
+	return s.Every(JobName, JobName, "0 * * * *", nil)
+}
+
+// Check revokes premium from users whose PremiumUntil has passed and sends
+// the reminders that are due. Only users expiring within the largest
+// reminder offset are loaded.
+func (w *Watcher) Check(now time.Time) {
+	horizon := now
+	if len(w.days) > 0 {
+		horizon = now.Add(time.Duration(w.days[0]) * 24 * time.Hour)
+	}
+
+	users, err := w.db.PremiumExpiringBy(horizon)
+	if err != nil {
+		log.Printf("Failed to load expiring premium users: %v", err)
+		return
+	}
+
+	for _, user := range users {
+		if !now.Before(user.PremiumUntil) {
+			w.expire(user.ID, now)
+			continue
+		}
+
+		w.remind(user.ID, user.PremiumUntil.Sub(now))
+	}
+}
+
+func (w *Watcher) expire(userID int64, now time.Time) {
+	expired := false
+	err := w.db.UpdateUser(userID, func(user *database.User) error {
+		expired = user.ExpirePremium(now)
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to expire premium of %d: %v", userID, err)
+		return
+	}
+	if !expired {
+		return
+	}
+
+	msg := tgbotapi.NewMessage(userID, "⌛ *Premium kamu telah berakhir.*\n\n"+
+		"Hubungi owner untuk memperpanjang akses premium.")
+	msg.ParseMode = "Markdown"
+	w.api.Send(msg)
+}
+
+// remind sends the closest reminder that is due and has not been sent yet.
+// Reminders for larger offsets are marked as sent too, so a bot that was
+// offline for a while sends one reminder instead of several.
+func (w *Watcher) remind(userID int64, left time.Duration) {
+	due := 0
+	for _, days := range w.days {
+		if left <= time.Duration(days)*24*time.Hour {
+			due = days
+		}
+	}
+	if due == 0 {
+		return
+	}
+
+	var until time.Time
+	send := false
+	err := w.db.UpdateUser(userID, func(user *database.User) error {
+		if contains(user.PremiumReminded, due) {
+			return nil
+		}
+		for _, days := range w.days {
+			if days >= due && !contains(user.PremiumReminded, days) {
+				user.PremiumReminded = append(user.PremiumReminded, days)
+			}
+		}
+		until = user.PremiumUntil
+		send = true
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to record premium reminder of %d: %v", userID, err)
+		return
+	}
+	if !send {
+		return
+	}
+
+	msg := tgbotapi.NewMessage(userID, fmt.Sprintf("⏰ *Pengingat Premium*\n\n"+
+		"Premium kamu akan berakhir dalam %s, pada %s.\n"+
+		"Hubungi owner untuk memperpanjang.",
+		formatLeft(left), until.In(w.loc).Format("2006-01-02 15:04")))
+	msg.ParseMode = "Markdown"
+	w.api.Send(msg)
+}
+
+func formatLeft(left time.Duration) string {
+	if days := int(left.Hours() / 24); days >= 1 {
+		return fmt.Sprintf("%d hari", days)
+	}
+	if hours := int(left.Hours()); hours >= 1 {
+		return fmt.Sprintf("%d jam", hours)
+	}
+	return "kurang dari 1 jam"
+}
+
+func contains(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package premium
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/levouinse/sofinco-bot/internal/config"
+	"github.com/levouinse/sofinco-bot/internal/database"
+	"github.com/levouinse/sofinco-bot/internal/telegram"
+)
+
+func TestWatcherCheck(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	wib := time.FixedZone("WIB", 7*60*60)
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	users := []*database.User{
+		{ID: 1, Premium: true, PremiumUntil: now.Add(-time.Minute)},
+		{ID: 2, Premium: true, PremiumUntil: now.Add(20 * time.Hour)},
+		{ID: 3, Premium: true, PremiumUntil: now.Add(30 * 24 * time.Hour)},
+		{ID: 4, Premium: true},
+		{ID: 5, PremiumUntil: now.Add(-time.Hour)},
+	}
+	for _, u := range users {
+		if err := db.SaveUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := telegram.NewRecorder()
+	w := NewWatcher(db, rec, &config.Config{Location: wib, PremiumReminderDays: []int{1, 3}})
+	w.Check(now)
+
+	var chats []int64
+	for _, c := range rec.Sent() {
+		chats = append(chats, telegram.ChatID(c))
+	}
+	if want := []int64{1, 2}; !reflect.DeepEqual(chats, want) {
+		t.Fatalf("messages sent to %v, want %v", chats, want)
+	}
+	texts := rec.Texts()
+	if !strings.Contains(texts[0], "berakhir") {
+		t.Errorf("expiry notice = %q", texts[0])
+	}
+	// 08:00 UTC the next day is 15:00 WIB.
+	if !strings.Contains(texts[1], "2024-03-11 15:00") {
+		t.Errorf("reminder = %q, want the expiry in WIB", texts[1])
+	}
+
+	expired, err := db.GetUser(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired.Premium {
+		t.Error("user 1 still premium after expiry")
+	}
+
+	// Nothing is sent twice, and the expired user left the index.
+	rec.Reset()
+	w.Check(now.Add(time.Hour))
+	if texts := rec.Texts(); len(texts) != 0 {
+		t.Errorf("second check sent %q", texts)
+	}
+	due, err := db.PremiumExpiringBy(now.Add(24 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 || due[0].ID != 2 {
+		t.Errorf("expiring users = %v, want only user 2", due)
+	}
+}
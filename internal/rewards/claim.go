@@ -0,0 +1,109 @@
+package rewards
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/levouinse/sofinco-bot/internal/config"
+	"github.com/levouinse/sofinco-bot/internal/database"
+	"github.com/levouinse/sofinco-bot/internal/limits"
+)
+
+// ErrAlreadyClaimed is returned by Claim when the period's reward was
+// already claimed.
+var ErrAlreadyClaimed = errors.New("already claimed")
+
+// Streak bonuses add StreakBonusPercent of the reward for every claim in a
+// row after the first, up to MaxStreakBonusPercent.
+const (
+	StreakBonusPercent    = 10
+	MaxStreakBonusPercent = 100
+)
+
+// Period is a reward that can be claimed once per period. A period starts
+// at midnight, in the configured timezone, of the day it was claimed and
+// lasts Days days, or of the first of the month and lasts Months months.
+type Period struct {
+	Name   string
+	Days   int
+	Months int
+	Reward Reward
+}
+
+var (
+	Daily   = Period{Name: "daily", Days: 1, Reward: Reward{Exp: 100, Money: 1000, Limit: 5}}
+	Weekly  = Period{Name: "weekly", Days: 7, Reward: Reward{Exp: 1000, Money: 10000, Limit: 25}}
+	Monthly = Period{Name: "monthly", Months: 1, Reward: Reward{Exp: 5000, Money: 50000, Limit: 100}}
+)
+
+// next returns when the period claimed at t ends.
+func (p Period) next(t time.Time, loc *time.Location) time.Time {
+	day := limits.StartOfDay(t, loc)
+	if p.Months > 0 {
+		// Months differ in length, so counting from the day claimed would
+		// overflow, e.g. Jan 31 plus a month is Mar 3.
+		day = day.AddDate(0, 0, 1-day.Day())
+	}
+	return day.AddDate(0, p.Months, p.Days)
+}
+
+// ClaimResult describes a claim.
+type ClaimResult struct {
+	Summary
+	// Streak is the number of periods in a row claimed, and StreakBonus
+	// the percentage it added to the reward.
+	Streak      int
+	StreakBonus int
+	// Next is when the period can be claimed again.
+	Next time.Time
+}
+
+// Claim grants the reward of period to userID unless they claimed it
+// already in the current period, in which case it returns
+// ErrAlreadyClaimed with Next set. Claiming in the period right after the
+// last claim continues the streak; skipping a period resets it.
+func Claim(db *database.Database, cfg *config.Config, userID int64, period Period, now time.Time) (ClaimResult, error) {
+	var result ClaimResult
+	err := db.UpdateBalance(userID, database.LedgerReward, period.Name, func(user *database.User) error {
+		last := user.Claims[period.Name]
+		streak := 1
+		if !last.LastAt.IsZero() {
+			next := period.next(last.LastAt, cfg.Location)
+			if now.Before(next) {
+				result.Next = next
+				result.Streak = last.Streak
+				return ErrAlreadyClaimed
+			}
+			if now.Before(period.next(next, cfg.Location)) {
+				streak = last.Streak + 1
+			}
+		}
+
+		bonus := (streak - 1) * StreakBonusPercent
+		if bonus > MaxStreakBonusPercent {
+			bonus = MaxStreakBonusPercent
+		}
+		reward := period.Reward
+		reward.Exp += reward.Exp * bonus / 100
+		reward.Money += reward.Money * bonus / 100
+		reward.Limit += reward.Limit * bonus / 100
+
+		if user.Claims == nil {
+			user.Claims = make(map[string]database.Claim)
+		}
+		user.Claims[period.Name] = database.Claim{LastAt: now, Streak: streak}
+		result.Summary = credit(user, cfg, reward)
+		result.Streak = streak
+		result.StreakBonus = bonus
+		result.Next = period.next(now, cfg.Location)
+		return nil
+	})
+	if errors.Is(err, ErrAlreadyClaimed) {
+		return result, err
+	}
+	if err != nil {
+		return ClaimResult{}, fmt.Errorf("gagal klaim hadiah %s: %w", period.Name, err)
+	}
+	return result, nil
+}
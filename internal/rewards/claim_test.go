@@ -0,0 +1,48 @@
+package rewards
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/levouinse/sofinco-bot/internal/config"
+	"github.com/levouinse/sofinco-bot/internal/database"
+)
+
+func TestMonthlyClaimAtMonthEnd(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.SaveUser(&database.User{ID: 610, FirstName: "Ana"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{Location: time.FixedZone("WIB", 7*60*60)}
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2026, month, day, hour, 0, 0, 0, cfg.Location)
+	}
+
+	for _, step := range []struct {
+		now    time.Time
+		streak int
+		next   time.Time
+	}{
+		{at(time.January, 31, 10), 1, at(time.February, 1, 0)},
+		{at(time.February, 1, 10), 2, at(time.March, 1, 0)},
+		{at(time.March, 31, 10), 3, at(time.April, 1, 0)},
+		{at(time.June, 1, 10), 1, at(time.July, 1, 0)},
+	} {
+		result, err := Claim(db, cfg, 610, Monthly, step.now)
+		if err != nil {
+			t.Fatalf("claim at %s: %v", step.now, err)
+		}
+		if result.Streak != step.streak || !result.Next.Equal(step.next) {
+			t.Errorf("claim at %s: streak %d, next %s; want %d, %s", step.now, result.Streak, result.Next, step.streak, step.next)
+		}
+	}
+	if _, err := Claim(db, cfg, 610, Monthly, at(time.June, 30, 10)); !errors.Is(err, ErrAlreadyClaimed) {
+		t.Errorf("second claim in June: err = %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package rewards
+
+import (
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/levouinse/sofinco-bot/internal/config"
+	"github.com/levouinse/sofinco-bot/internal/database"
+	"github.com/levouinse/sofinco-bot/internal/telegram"
+)
+
+// Curve defines how much XP the levels take. Going from level n-1 to n
+// takes Base + Step*(n-1) XP, so with the defaults level 1 takes 100 XP,
+// level 2 another 150 and so on. Everyone starts at level 0.
+type Curve struct {
+	Base int
+	Step int
+}
+
+// CurveFor returns the curve configured in cfg, falling back to the
+// defaults for unset values.
+func CurveFor(cfg *config.Config) Curve {
+	c := Curve{Base: cfg.LevelBaseXP, Step: cfg.LevelStepXP}
+	if c.Base <= 0 {
+		c.Base = 100
+	}
+	if c.Step <= 0 {
+		c.Step = 50
+	}
+	return c
+}
+
+// Total returns the XP needed to reach level from zero.
+func (c Curve) Total(level int) int {
+	if level <= 0 {
+		return 0
+	}
+	return level*c.Base + c.Step*level*(level-1)/2
+}
+
+// Level returns the level reached with exp XP.
+func (c Curve) Level(exp int) int {
+	level := 0
+	for c.Total(level+1) <= exp {
+		level++
+	}
+	return level
+}
+
+// Milestones are extra rewards for reaching a level. They are not
+// multiplied for premium users.
+var Milestones = map[int]Reward{
+	5:   {DailyLimit: 5},
+	10:  {DailyLimit: 10, Money: 5000},
+	25:  {DailyLimit: 25, Money: 25000},
+	50:  {DailyLimit: 50, Money: 100000},
+	100: {DailyLimit: 100, Money: 500000},
+}
+
+// NextMilestone returns the first milestone above level.
+func NextMilestone(level int) (int, Reward, bool) {
+	next := 0
+	for l := range Milestones {
+		if l > level && (next == 0 || l < next) {
+			next = l
+		}
+	}
+	if next == 0 {
+		return 0, Reward{}, false
+	}
+	return next, Milestones[next], true
+}
+
+// levelUp brings user.Level in line with their XP and applies the
+// milestones they passed on the way, adding them to bonus.
+func levelUp(user *database.User, curve Curve, bonus *Reward) {
+	for {
+		level := curve.Level(user.Exp)
+		if level <= user.Level {
+			// Levels are never taken away, even if the curve was made
+			// steeper, so each milestone is only granted once.
+			return
+		}
+		for l := user.Level + 1; l <= level; l++ {
+			if m, ok := Milestones[l]; ok {
+				m.apply(user)
+				*bonus = bonus.add(m)
+			}
+		}
+		user.Level = level
+	}
+}
+
+// Announce posts a level-up message in chatID if summary has one.
+func Announce(api telegram.Sender, chatID int64, summary Summary) {
+	if !summary.LeveledUp() {
+		return
+	}
+
+	text := fmt.Sprintf("🎉 Level Up!\n\n%s naik ke level %d!", summary.Name, summary.Level)
+	if bonus := summary.Bonus.String(); bonus != "" {
+		text += "\n🎁 Bonus: " + bonus
+	}
+	api.Send(tgbotapi.NewMessage(chatID, text))
+}
@@ -0,0 +1,120 @@
+// Package rewards credits users with what they earn, such as winning a
+// game, and levels them up as their XP grows.
+package rewards
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/levouinse/sofinco-bot/internal/config"
+	"github.com/levouinse/sofinco-bot/internal/database"
+)
+
+// Reward is what a user earns. Zero fields are not granted.
+type Reward struct {
+	Exp   int
+	Money int
+	Limit int
+	// DailyLimit permanently raises the limit the user is topped up to
+	// every day.
+	DailyLimit int
+}
+
+// String renders the reward as e.g. "+100 XP, +2000 Money".
+func (r Reward) String() string {
+	var parts []string
+	if r.Exp != 0 {
+		parts = append(parts, fmt.Sprintf("+%d XP", r.Exp))
+	}
+	if r.Money != 0 {
+		parts = append(parts, fmt.Sprintf("+%d Money", r.Money))
+	}
+	if r.Limit != 0 {
+		parts = append(parts, fmt.Sprintf("+%d Limit", r.Limit))
+	}
+	if r.DailyLimit != 0 {
+		parts = append(parts, fmt.Sprintf("+%d Daily Limit", r.DailyLimit))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (r Reward) add(o Reward) Reward {
+	return Reward{
+		Exp:        r.Exp + o.Exp,
+		Money:      r.Money + o.Money,
+		Limit:      r.Limit + o.Limit,
+		DailyLimit: r.DailyLimit + o.DailyLimit,
+	}
+}
+
+func (r Reward) apply(user *database.User) {
+	user.Exp += r.Exp
+	user.Money += r.Money
+	user.Limit += r.Limit + r.DailyLimit
+	user.BonusLimit += r.DailyLimit
+}
+
+// Summary describes a granted reward so it can be shown to the user.
+type Summary struct {
+	// Reward is what was actually credited, after multipliers.
+	Reward
+	// Multiplier is the premium multiplier that was applied, or 1.
+	Multiplier int
+
+	// Name is the first name of the rewarded user.
+	Name string
+	// LevelFrom and Level are the user's level before and after the
+	// reward. Bonus holds the milestone rewards of the levels reached.
+	LevelFrom int
+	Level     int
+	Bonus     Reward
+}
+
+// LeveledUp reports whether the reward made the user reach a new level.
+func (s Summary) LeveledUp() bool {
+	return s.Level > s.LevelFrom
+}
+
+// String renders the summary as e.g. "+100 XP, +2000 Money (premium x2)".
+func (s Summary) String() string {
+	text := s.Reward.String()
+	if text != "" && s.Multiplier > 1 {
+		text += fmt.Sprintf(" (premium x%d)", s.Multiplier)
+	}
+	return text
+}
+
+// Grant credits reward to userID in a single transaction and levels them
+// up if they earned enough XP. XP and money are multiplied by
+// cfg.PremiumMultiplier for premium users; limits are not, since premium
+// users already get larger ones. Money is recorded in the ledger.
+func Grant(db *database.Database, cfg *config.Config, userID int64, reward Reward) (Summary, error) {
+	var summary Summary
+	err := db.UpdateBalance(userID, database.LedgerReward, "", func(user *database.User) error {
+		summary = credit(user, cfg, reward)
+		return nil
+	})
+	if err != nil {
+		return Summary{}, fmt.Errorf("gagal memberi hadiah: %w", err)
+	}
+	return summary, nil
+}
+
+// credit applies reward to user as described for Grant.
+func credit(user *database.User, cfg *config.Config, reward Reward) Summary {
+	summary := Summary{Reward: reward, Multiplier: 1}
+	user.ExpirePremium(time.Now())
+	if user.Premium && cfg.PremiumMultiplier > 1 {
+		summary.Multiplier = cfg.PremiumMultiplier
+		summary.Exp = reward.Exp * cfg.PremiumMultiplier
+		summary.Money = reward.Money * cfg.PremiumMultiplier
+	}
+
+	summary.Name = user.FirstName
+	summary.LevelFrom = user.Level
+	summary.Reward.apply(user)
+	levelUp(user, CurveFor(cfg), &summary.Bonus)
+	summary.Level = user.Level
+	return summary
+}
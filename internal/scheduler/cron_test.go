@@ -0,0 +1,163 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		spec string
+		ok   bool
+	}{
+		{"* * * * *", true},
+		{"@daily", true},
+		{" @hourly ", true},
+		{"*/15 9-17 * * 1-5", true},
+		{"0 0 1,15 * *", true},
+		{"0 0 29 2 *", true},
+		{"0 0 31 2 1", true},
+		{"", false},
+		{"* * * *", false},
+		{"* * * * * *", false},
+		{"60 * * * *", false},
+		{"* 24 * * *", false},
+		{"* * 0 * *", false},
+		{"* * * 13 *", false},
+		{"* * * * 7", false},
+		{"*/0 * * * *", false},
+		{"5-1 * * * *", false},
+		{"a * * * *", false},
+		{"@yearly", false},
+		{"0 0 31 2 *", false},
+		{"0 0 30 2 *", false},
+		{"0 0 31 4,6,9,11 *", false},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseCron(tt.spec)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseCron(%q) error = %v, want ok %v", tt.spec, err, tt.ok)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*60*60)
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no timezone database: %v", err)
+	}
+	// Chile moves its clocks at midnight, so some days have no 00:00.
+	santiago, err := time.LoadLocation("America/Santiago")
+	if err != nil {
+		t.Skipf("no timezone database: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "midnight",
+			spec: "0 0 * * *",
+			from: time.Date(2024, 3, 10, 15, 30, 0, 0, wib),
+			want: time.Date(2024, 3, 11, 0, 0, 0, 0, wib),
+		},
+		{
+			name: "step",
+			spec: "*/15 * * * *",
+			from: time.Date(2024, 3, 10, 10, 7, 42, 0, wib),
+			want: time.Date(2024, 3, 10, 10, 15, 0, 0, wib),
+		},
+		{
+			name: "strictly after",
+			spec: "*/15 * * * *",
+			from: time.Date(2024, 3, 10, 10, 15, 0, 0, wib),
+			want: time.Date(2024, 3, 10, 10, 30, 0, 0, wib),
+		},
+		{
+			name: "short month skipped",
+			spec: "0 0 31 * *",
+			from: time.Date(2024, 4, 15, 0, 0, 0, 0, wib),
+			want: time.Date(2024, 5, 31, 0, 0, 0, 0, wib),
+		},
+		{
+			name: "leap day",
+			spec: "0 0 29 2 *",
+			from: time.Date(2023, 3, 1, 0, 0, 0, 0, wib),
+			want: time.Date(2024, 2, 29, 0, 0, 0, 0, wib),
+		},
+		{
+			name: "year rollover",
+			spec: "@monthly",
+			from: time.Date(2024, 12, 31, 23, 59, 0, 0, wib),
+			want: time.Date(2025, 1, 1, 0, 0, 0, 0, wib),
+		},
+		{
+			name: "day of week",
+			spec: "0 9 * * 1",
+			from: time.Date(2024, 3, 10, 12, 0, 0, 0, wib),
+			want: time.Date(2024, 3, 11, 9, 0, 0, 0, wib),
+		},
+		{
+			// Both day fields restricted: the 13th or any Friday.
+			name: "day of month or week",
+			spec: "0 0 13 * 5",
+			from: time.Date(2024, 9, 1, 0, 0, 0, 0, wib),
+			want: time.Date(2024, 9, 6, 0, 0, 0, 0, wib),
+		},
+		{
+			name: "location of from",
+			spec: "0 0 * * *",
+			from: time.Date(2024, 3, 10, 16, 59, 0, 0, time.UTC).In(wib),
+			want: time.Date(2024, 3, 11, 0, 0, 0, 0, wib),
+		},
+		{
+			// 02:30 does not exist when clocks spring forward.
+			name: "dst gap",
+			spec: "30 2 * * *",
+			from: time.Date(2024, 3, 9, 12, 0, 0, 0, ny),
+			want: time.Date(2024, 3, 11, 2, 30, 0, 0, ny),
+		},
+		{
+			name: "dst overlap first",
+			spec: "30 1 * * *",
+			from: time.Date(2024, 11, 3, 0, 0, 0, 0, ny),
+			want: time.Date(2024, 11, 3, 1, 30, 0, 0, ny),
+		},
+		{
+			// 01:30 happens twice when clocks fall back; run only once.
+			name: "dst overlap once",
+			spec: "30 1 * * *",
+			from: time.Date(2024, 11, 3, 1, 30, 0, 0, ny),
+			want: time.Date(2024, 11, 4, 1, 30, 0, 0, ny),
+		},
+		{
+			name: "dst gap at midnight",
+			spec: "0 0 * * *",
+			from: time.Date(2024, 9, 7, 12, 0, 0, 0, santiago),
+			want: time.Date(2024, 9, 9, 0, 0, 0, 0, santiago),
+		},
+		{
+			name: "dst gap on the day",
+			spec: "0 0 8 9 *",
+			from: time.Date(2024, 9, 7, 12, 0, 0, 0, santiago),
+			want: time.Date(2025, 9, 8, 0, 0, 0, 0, santiago),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
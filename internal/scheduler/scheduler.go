@@ -0,0 +1,284 @@
+// Package scheduler runs one-shot and recurring jobs that are stored in the
+// database, so they survive restarts. Jobs that came due while the bot was
+// offline run as soon as it starts again.
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/levouinse/sofinco-bot/internal/config"
+	"github.com/levouinse/sofinco-bot/internal/database"
+	"github.com/levouinse/sofinco-bot/internal/telegram"
+)
+
+// RetryDelay is how long a failed one-shot job waits before it is tried
+// again, and MaxAttempts how many times it is tried in total.
+const (
+	RetryDelay  = time.Minute
+	MaxAttempts = 5
+)
+
+// Context is passed to job handlers.
+type Context struct {
+	Ctx       context.Context
+	API       telegram.Sender
+	DB        *database.Database
+	Config    *config.Config
+	Scheduler *Scheduler
+	Job       *database.Job
+}
+
+// Decode unmarshals the job payload into v.
+func (ctx *Context) Decode(v interface{}) error {
+	if len(ctx.Job.Payload) == 0 {
+		return nil
+	}
+	return json.Unmarshal(ctx.Job.Payload, v)
+}
+
+// HandlerFunc runs a job.
+type HandlerFunc func(ctx *Context) error
+
+var (
+	handlersMu sync.RWMutex
+	handlers   = make(map[string]HandlerFunc)
+)
+
+// Handle registers the handler for jobs called name. Plugins usually call
+// it from init. Jobs that come due without a handler are dropped.
+func Handle(name string, fn HandlerFunc) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	handlers[name] = fn
+}
+
+func handlerFor(name string) HandlerFunc {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	return handlers[name]
+}
+
+type Scheduler struct {
+	api    telegram.Sender
+	db     *database.Database
+	config *config.Config
+	wake   chan struct{}
+}
+
+func New(api telegram.Sender, db *database.Database, cfg *config.Config) *Scheduler {
+	return &Scheduler{
+		api:    api,
+		db:     db,
+		config: cfg,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// At schedules a one-shot job. A job with the same id is replaced.
+func (s *Scheduler) At(id, name string, at time.Time, payload interface{}) error {
+	raw, err := marshalPayload(payload)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.SaveJob(&database.Job{ID: id, Name: name, Payload: raw, RunAt: at}); err != nil {
+		return err
+	}
+	s.notify()
+	return nil
+}
+
+// Every schedules a recurring job on a cron spec, evaluated in the
+// configured timezone. If the job already exists with the same spec its
+// next run is kept, so a run missed while the bot was offline still
+// happens on startup.
+func (s *Scheduler) Every(id, name, spec string, payload interface{}) error {
+	cron, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+	raw, err := marshalPayload(payload)
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.db.GetJob(id)
+	if err != nil {
+		return err
+	}
+
+	job := &database.Job{ID: id, Name: name, Payload: raw, Spec: spec}
+	if existing != nil && existing.Spec == spec && !existing.RunAt.IsZero() {
+		job.RunAt = existing.RunAt
+		job.LastRun = existing.LastRun
+	} else {
+		job.RunAt = cron.Next(time.Now().In(s.config.Location))
+		if job.RunAt.IsZero() {
+			return fmt.Errorf("cron %q has no upcoming run", spec)
+		}
+	}
+
+	if err := s.db.SaveJob(job); err != nil {
+		return err
+	}
+	s.notify()
+	return nil
+}
+
+// Cancel removes a job.
+func (s *Scheduler) Cancel(id string) error {
+	if err := s.db.DeleteJob(id); err != nil {
+		return err
+	}
+	s.notify()
+	return nil
+}
+
+// Run executes jobs as they come due until ctx is cancelled. Jobs run one
+// at a time.
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		next := s.runDue(ctx)
+
+		wait := time.Hour
+		if !next.IsZero() {
+			wait = time.Until(next)
+		}
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-s.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// runDue runs every job that is due and returns when the next one is.
+func (s *Scheduler) runDue(ctx context.Context) time.Time {
+	jobs, err := s.db.GetJobs()
+	if err != nil {
+		log.Printf("Failed to load jobs: %v", err)
+		return time.Now().Add(RetryDelay)
+	}
+
+	var next time.Time
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			return next
+		}
+		if job.RunAt.After(time.Now()) {
+			if next.IsZero() || job.RunAt.Before(next) {
+				next = job.RunAt
+			}
+			continue
+		}
+
+		if at := s.run(ctx, job); !at.IsZero() && (next.IsZero() || at.Before(next)) {
+			next = at
+		}
+	}
+	return next
+}
+
+// run executes a single job and stores its follow-up state. It returns
+// when the job should run next, or the zero time if it is done.
+func (s *Scheduler) run(ctx context.Context, job *database.Job) time.Time {
+	fn := handlerFor(job.Name)
+	if fn == nil {
+		log.Printf("No handler for job %s (%s), dropping it", job.ID, job.Name)
+		s.db.DeleteJob(job.ID)
+		return time.Time{}
+	}
+
+	err := s.call(fn, &Context{
+		Ctx:       ctx,
+		API:       s.api,
+		DB:        s.db,
+		Config:    s.config,
+		Scheduler: s,
+		Job:       job,
+	})
+	now := time.Now()
+	if err != nil {
+		log.Printf("Job %s (%s) failed: %v", job.ID, job.Name, err)
+	}
+
+	// The handler may have rescheduled or cancelled its own job.
+	current, loadErr := s.db.GetJob(job.ID)
+	if loadErr != nil || current == nil || !current.RunAt.Equal(job.RunAt) {
+		if current != nil {
+			return current.RunAt
+		}
+		return time.Time{}
+	}
+
+	if job.Spec != "" {
+		cron, parseErr := ParseCron(job.Spec)
+		if parseErr != nil {
+			log.Printf("Dropping job %s: %v", job.ID, parseErr)
+			s.db.DeleteJob(job.ID)
+			return time.Time{}
+		}
+		job.LastRun = now
+		job.RunAt = cron.Next(now.In(s.config.Location))
+		if job.RunAt.IsZero() {
+			log.Printf("Dropping job %s: cron %q has no upcoming run", job.ID, job.Spec)
+			s.db.DeleteJob(job.ID)
+			return time.Time{}
+		}
+		s.save(job)
+		return job.RunAt
+	}
+
+	job.Attempts++
+	if err == nil || job.Attempts >= MaxAttempts {
+		s.db.DeleteJob(job.ID)
+		return time.Time{}
+	}
+	job.LastRun = now
+	job.RunAt = now.Add(RetryDelay)
+	s.save(job)
+	return job.RunAt
+}
+
+func (s *Scheduler) call(fn HandlerFunc, ctx *Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
+
+func (s *Scheduler) save(job *database.Job) {
+	if err := s.db.SaveJob(job); err != nil {
+		log.Printf("Failed to save job %s: %v", job.ID, err)
+	}
+}
+
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func marshalPayload(payload interface{}) (json.RawMessage, error) {
+	if payload == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid job payload: %w", err)
+	}
+	return raw, nil
+}
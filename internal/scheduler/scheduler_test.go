@@ -0,0 +1,148 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/levouinse/sofinco-bot/internal/config"
+	"github.com/levouinse/sofinco-bot/internal/database"
+	"github.com/levouinse/sofinco-bot/internal/telegram"
+)
+
+func newTestScheduler(t *testing.T) (*Scheduler, *database.Database) {
+	t.Helper()
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	wib := time.FixedZone("WIB", 7*60*60)
+	return New(telegram.NewRecorder(), db, &config.Config{Location: wib}), db
+}
+
+func getJob(t *testing.T, db *database.Database, id string) *database.Job {
+	t.Helper()
+	job, err := db.GetJob(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+func TestEveryKeepsMissedRun(t *testing.T) {
+	s, db := newTestScheduler(t)
+
+	if err := s.Every("reset", "test.every", "0 0 * * *", nil); err != nil {
+		t.Fatal(err)
+	}
+	job := getJob(t, db, "reset")
+	if job == nil || !job.RunAt.After(time.Now()) || job.RunAt.In(s.config.Location).Hour() != 0 {
+		t.Fatalf("job = %+v, want the next midnight", job)
+	}
+
+	// A run that came due while the bot was offline survives a restart
+	// that registers the same job again.
+	missed := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	job.RunAt = missed
+	if err := db.SaveJob(job); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Every("reset", "test.every", "0 0 * * *", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := getJob(t, db, "reset").RunAt; !got.Equal(missed) {
+		t.Errorf("RunAt = %v, want the missed run %v", got, missed)
+	}
+
+	// A new spec replaces the schedule.
+	if err := s.Every("reset", "test.every", "0 12 * * *", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := getJob(t, db, "reset").RunAt; !got.After(time.Now()) {
+		t.Errorf("RunAt = %v after changing the spec, want a future run", got)
+	}
+
+	if err := s.Every("never", "test.every", "0 0 31 2 *", nil); err == nil {
+		t.Error("Every accepted a spec that never matches")
+	}
+}
+
+func TestRunDue(t *testing.T) {
+	s, db := newTestScheduler(t)
+
+	type payload struct{ N int }
+	var got []int
+	Handle("test.once", func(ctx *Context) error {
+		var p payload
+		if err := ctx.Decode(&p); err != nil {
+			return err
+		}
+		got = append(got, p.N)
+		return nil
+	})
+	Handle("test.fail", func(ctx *Context) error {
+		return errors.New("boom")
+	})
+	Handle("test.recurring", func(ctx *Context) error { return nil })
+
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	for _, job := range []struct {
+		id, name string
+		at       time.Time
+	}{
+		{"once", "test.once", past},
+		{"later", "test.once", future},
+		{"fail", "test.fail", past},
+		{"orphan", "test.missing", past},
+	} {
+		if err := s.At(job.id, job.name, job.at, payload{N: len(job.id)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Every("recurring", "test.recurring", "*/5 * * * *", nil); err != nil {
+		t.Fatal(err)
+	}
+	recurring := getJob(t, db, "recurring")
+	recurring.RunAt = past
+	if err := db.SaveJob(recurring); err != nil {
+		t.Fatal(err)
+	}
+
+	next := s.runDue(context.Background())
+
+	if len(got) != 1 || got[0] != len("once") {
+		t.Errorf("test.once ran with %v, want only the due job", got)
+	}
+	if getJob(t, db, "once") != nil {
+		t.Error("finished one-shot job was kept")
+	}
+	if getJob(t, db, "orphan") != nil {
+		t.Error("job without a handler was kept")
+	}
+	if getJob(t, db, "later") == nil {
+		t.Error("job that is not due yet was removed")
+	}
+
+	fail := getJob(t, db, "fail")
+	if fail == nil || fail.Attempts != 1 || !fail.RunAt.After(time.Now()) {
+		t.Errorf("failed job = %+v, want a retry", fail)
+	}
+
+	recurring = getJob(t, db, "recurring")
+	if recurring == nil || recurring.LastRun.IsZero() || recurring.RunAt.Minute()%5 != 0 {
+		t.Fatalf("recurring job = %+v, want it rescheduled", recurring)
+	}
+
+	want := fail.RunAt
+	if recurring.RunAt.Before(want) {
+		want = recurring.RunAt
+	}
+	if !next.Equal(want) {
+		t.Errorf("next run = %v, want %v", next, want)
+	}
+}
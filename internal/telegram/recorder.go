@@ -0,0 +1,134 @@
+package telegram
+
+import (
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Recorder is an in-memory Sender that keeps every request it receives
+// instead of sending it to Telegram.
+type Recorder struct {
+	mu        sync.Mutex
+	sent      []tgbotapi.Chattable
+	requested []tgbotapi.Chattable
+	files     []tgbotapi.FileConfig
+	nextID    int
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (r *Recorder) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sent = append(r.sent, c)
+	r.nextID++
+
+	return tgbotapi.Message{
+		MessageID: r.nextID,
+		Chat:      &tgbotapi.Chat{ID: ChatID(c)},
+		Text:      Text(c),
+	}, nil
+}
+
+func (r *Recorder) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requested = append(r.requested, c)
+	return &tgbotapi.APIResponse{Ok: true, Result: []byte("true")}, nil
+}
+
+func (r *Recorder) GetFile(config tgbotapi.FileConfig) (tgbotapi.File, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.files = append(r.files, config)
+	return tgbotapi.File{FileID: config.FileID, FilePath: "files/" + config.FileID}, nil
+}
+
+// Sent returns everything passed to Send, in order.
+func (r *Recorder) Sent() []tgbotapi.Chattable {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]tgbotapi.Chattable(nil), r.sent...)
+}
+
+// Requested returns everything passed to Request, in order.
+func (r *Recorder) Requested() []tgbotapi.Chattable {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]tgbotapi.Chattable(nil), r.requested...)
+}
+
+// Files returns every GetFile request, in order.
+func (r *Recorder) Files() []tgbotapi.FileConfig {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]tgbotapi.FileConfig(nil), r.files...)
+}
+
+// Texts returns the text of every sent message or caption, in order.
+func (r *Recorder) Texts() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var texts []string
+	for _, c := range r.sent {
+		if text := Text(c); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return texts
+}
+
+// Reset forgets everything recorded so far.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = nil
+	r.requested = nil
+	r.files = nil
+}
+
+// ChatID returns the chat a request is addressed to, or 0 if it is not
+// a chat-bound request.
+func ChatID(c tgbotapi.Chattable) int64 {
+	switch v := c.(type) {
+	case tgbotapi.MessageConfig:
+		return v.ChatID
+	case tgbotapi.PhotoConfig:
+		return v.ChatID
+	case tgbotapi.AudioConfig:
+		return v.ChatID
+	case tgbotapi.VideoConfig:
+		return v.ChatID
+	case tgbotapi.StickerConfig:
+		return v.ChatID
+	case tgbotapi.EditMessageTextConfig:
+		return v.ChatID
+	case tgbotapi.DeleteMessageConfig:
+		return v.ChatID
+	}
+	return 0
+}
+
+// Text returns the text or caption carried by a request.
+func Text(c tgbotapi.Chattable) string {
+	switch v := c.(type) {
+	case tgbotapi.MessageConfig:
+		return v.Text
+	case tgbotapi.EditMessageTextConfig:
+		return v.Text
+	case tgbotapi.PhotoConfig:
+		return v.Caption
+	case tgbotapi.AudioConfig:
+		return v.Caption
+	case tgbotapi.VideoConfig:
+		return v.Caption
+	}
+	return ""
+}
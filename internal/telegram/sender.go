@@ -0,0 +1,15 @@
+package telegram
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Sender is the part of the Telegram Bot API that handlers and plugins use
+// to talk to Telegram. *tgbotapi.BotAPI satisfies it.
+type Sender interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+	GetFile(config tgbotapi.FileConfig) (tgbotapi.File, error)
+}
+
+var _ Sender = (*tgbotapi.BotAPI)(nil)
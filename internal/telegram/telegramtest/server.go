@@ -0,0 +1,313 @@
+// Package telegramtest provides a local stand-in for the Telegram Bot API
+// so the bot can be exercised end to end without a real token.
+package telegramtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// BotID and BotUsername identify the fake bot returned by getMe.
+const (
+	BotID       int64 = 1000
+	BotUsername       = "sofinco_test_bot"
+)
+
+// Call is a single request made by the bot against the fake server.
+type Call struct {
+	Method string
+	Params url.Values
+}
+
+// Server is a fake Bot API. Point tgbotapi.NewBotAPIWithAPIEndpoint at
+// Endpoint, feed it updates with PushUpdate and inspect what the bot sent
+// with Calls or WaitFor.
+type Server struct {
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	updates  []tgbotapi.Update
+	nextID   int
+	nextMsg  int
+	calls    []Call
+	notify   chan struct{}
+	closed   chan struct{}
+	closeOne sync.Once
+
+	// Admins lists the administrators returned by getChatAdministrators,
+	// keyed by chat ID.
+	Admins map[int64][]int64
+}
+
+func NewServer() *Server {
+	s := &Server{
+		nextID: 1,
+		notify: make(chan struct{}),
+		closed: make(chan struct{}),
+		Admins: make(map[int64][]int64),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Endpoint returns the API endpoint format expected by
+// tgbotapi.NewBotAPIWithAPIEndpoint.
+func (s *Server) Endpoint() string {
+	return s.srv.URL + "/bot%s/%s"
+}
+
+// NewBotAPI returns a client connected to the fake server.
+func (s *Server) NewBotAPI() (*tgbotapi.BotAPI, error) {
+	return tgbotapi.NewBotAPIWithAPIEndpoint("TEST:token", s.Endpoint())
+}
+
+func (s *Server) Close() {
+	s.closeOne.Do(func() { close(s.closed) })
+	s.srv.Close()
+}
+
+// PushUpdate queues an update for the next getUpdates call. The update ID
+// is assigned by the server.
+func (s *Server) PushUpdate(update tgbotapi.Update) {
+	s.mu.Lock()
+	update.UpdateID = s.nextID
+	s.nextID++
+	s.updates = append(s.updates, update)
+	s.broadcast()
+	s.mu.Unlock()
+}
+
+// PushMessage queues a text message sent by userID in chatID. Negative chat
+// IDs are treated as groups.
+func (s *Server) PushMessage(chatID, userID int64, text string) {
+	s.PushUpdate(tgbotapi.Update{Message: s.newMessage(chatID, userID, text)})
+}
+
+// PushCallback queues a press of an inline button with the given data on
+// message messageID.
+func (s *Server) PushCallback(chatID, userID int64, messageID int, data string) {
+	s.mu.Lock()
+	id := strconv.Itoa(s.nextID)
+	s.mu.Unlock()
+
+	s.PushUpdate(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:   id,
+		From: newUser(userID),
+		Message: &tgbotapi.Message{
+			MessageID: messageID,
+			From:      newUser(BotID),
+			Chat:      newChat(chatID),
+			Date:      int(time.Now().Unix()),
+		},
+		Data: data,
+	}})
+}
+
+func (s *Server) newMessage(chatID, userID int64, text string) *tgbotapi.Message {
+	s.mu.Lock()
+	s.nextMsg++
+	id := s.nextMsg
+	s.mu.Unlock()
+
+	msg := &tgbotapi.Message{
+		MessageID: id,
+		From:      newUser(userID),
+		Chat:      newChat(chatID),
+		Date:      int(time.Now().Unix()),
+		Text:      text,
+	}
+	if strings.HasPrefix(text, "/") {
+		end := strings.IndexAny(text, " \n")
+		if end < 0 {
+			end = len(text)
+		}
+		msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: end}}
+	}
+	return msg
+}
+
+// Calls returns every request made so far, in order.
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// CallsTo returns the requests made to method, in order.
+func (s *Server) CallsTo(method string) []Call {
+	var calls []Call
+	for _, c := range s.Calls() {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// WaitFor blocks until a call to method matching match has been made after
+// the first skip calls to that method, and returns it.
+func (s *Server) WaitFor(method string, skip int, match func(Call) bool, timeout time.Duration) (Call, error) {
+	deadline := time.After(timeout)
+	for {
+		s.mu.Lock()
+		seen := 0
+		for _, c := range s.calls {
+			if c.Method != method {
+				continue
+			}
+			seen++
+			if seen > skip && (match == nil || match(c)) {
+				s.mu.Unlock()
+				return c, nil
+			}
+		}
+		notify := s.notify
+		s.mu.Unlock()
+
+		select {
+		case <-notify:
+		case <-deadline:
+			return Call{}, fmt.Errorf("timed out waiting for %s", method)
+		}
+	}
+}
+
+// broadcast wakes up everyone waiting on the server. s.mu must be held.
+func (s *Server) broadcast() {
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	method := parts[len(parts)-1]
+
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		r.ParseForm()
+	}
+	params := r.Form
+
+	if method == "getUpdates" {
+		s.writeResult(w, s.getUpdates(params))
+		return
+	}
+
+	s.mu.Lock()
+	s.calls = append(s.calls, Call{Method: method, Params: params})
+	s.broadcast()
+	s.mu.Unlock()
+
+	switch method {
+	case "getMe":
+		s.writeResult(w, tgbotapi.User{ID: BotID, IsBot: true, FirstName: "Sofinco", UserName: BotUsername})
+	case "sendMessage", "sendPhoto", "sendAudio", "sendVideo", "sendSticker", "sendDocument", "editMessageText":
+		s.writeResult(w, s.sentMessage(params))
+	case "getFile":
+		fileID := params.Get("file_id")
+		s.writeResult(w, tgbotapi.File{FileID: fileID, FilePath: "files/" + fileID})
+	case "getChatAdministrators":
+		s.writeResult(w, s.admins(params))
+	case "getMyCommands":
+		s.writeResult(w, []tgbotapi.BotCommand{})
+	default:
+		s.writeResult(w, true)
+	}
+}
+
+func (s *Server) getUpdates(params url.Values) []tgbotapi.Update {
+	offset, _ := strconv.Atoi(params.Get("offset"))
+	timeout, _ := strconv.Atoi(params.Get("timeout"))
+	deadline := time.After(time.Duration(timeout) * time.Second)
+
+	for {
+		s.mu.Lock()
+		var pending []tgbotapi.Update
+		for _, u := range s.updates {
+			if u.UpdateID >= offset {
+				pending = append(pending, u)
+			}
+		}
+		notify := s.notify
+		s.mu.Unlock()
+
+		if len(pending) > 0 || timeout == 0 {
+			return pending
+		}
+
+		select {
+		case <-notify:
+		case <-deadline:
+			return pending
+		case <-s.closed:
+			return pending
+		}
+	}
+}
+
+func (s *Server) sentMessage(params url.Values) tgbotapi.Message {
+	chatID, _ := strconv.ParseInt(params.Get("chat_id"), 10, 64)
+
+	s.mu.Lock()
+	id, _ := strconv.Atoi(params.Get("message_id"))
+	if id == 0 {
+		s.nextMsg++
+		id = s.nextMsg
+	}
+	s.mu.Unlock()
+
+	text := params.Get("text")
+	if text == "" {
+		text = params.Get("caption")
+	}
+
+	return tgbotapi.Message{
+		MessageID: id,
+		From:      newUser(BotID),
+		Chat:      newChat(chatID),
+		Date:      int(time.Now().Unix()),
+		Text:      text,
+	}
+}
+
+func (s *Server) admins(params url.Values) []tgbotapi.ChatMember {
+	chatID, _ := strconv.ParseInt(params.Get("chat_id"), 10, 64)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members := []tgbotapi.ChatMember{}
+	for _, id := range s.Admins[chatID] {
+		members = append(members, tgbotapi.ChatMember{User: newUser(id), Status: "administrator"})
+	}
+	return members
+}
+
+func (s *Server) writeResult(w http.ResponseWriter, result interface{}) {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: true, Result: raw})
+}
+
+func newUser(id int64) *tgbotapi.User {
+	return &tgbotapi.User{ID: id, FirstName: fmt.Sprintf("User%d", id), UserName: fmt.Sprintf("user%d", id)}
+}
+
+func newChat(id int64) *tgbotapi.Chat {
+	if id < 0 {
+		return &tgbotapi.Chat{ID: id, Type: "supergroup", Title: "Test Group"}
+	}
+	return &tgbotapi.Chat{ID: id, Type: "private"}
+}